@@ -2,6 +2,7 @@ package ast
 
 import (
 	"bytes"
+	"math/big"
 	"monkey/token"
 	"strings"
 )
@@ -10,6 +11,8 @@ import (
 type Node interface {
 	TokenLiteral() string // returns at actual litral of the node(from the token refering to this node)
 	String() string       // return the semantic value of the node
+	Pos() token.Position  // position of the first character of the node
+	End() token.Position  // position just past the last character of the node
 }
 
 // interface for a statement nodes
@@ -40,6 +43,36 @@ func (p *Program) TokenLiteral() string {
 	}
 }
 
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
+func (p *Program) End() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[len(p.Statements)-1].End()
+	}
+	return token.Position{}
+}
+
+// returns the end of n, falling back to the end of tok when n is missing (after a parse error)
+func endOf(n Node, tok token.Token) token.Position {
+	if n == nil {
+		return tok.Span.End
+	}
+	return n.End()
+}
+
+// returns the end of a closing delimiter, or of the opening one when the parser never reached it
+func closingEnd(closing token.Token, opening token.Token) token.Position {
+	if closing.Span.End.IsValid() {
+		return closing.Span.End
+	}
+	return opening.Span.End
+}
+
 // returns a string that comprises of the (return of String() methods) for the each node(that implements the Statement node) in the Statements slice
 func (p *Program) String() string {
 	var out bytes.Buffer
@@ -60,6 +93,8 @@ type Identifier struct {
 func (i *Identifier) expressionNode()      {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
 func (i *Identifier) String() string       { return i.Value }
+func (i *Identifier) Pos() token.Position  { return i.Token.Span.Start }
+func (i *Identifier) End() token.Position  { return i.Token.Span.End }
 
 /*
 let x = 5
@@ -81,6 +116,13 @@ type LetStatement struct {
 
 func (ls *LetStatement) statementNode()       {}
 func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal } // returns token literal in this case --> let
+func (ls *LetStatement) Pos() token.Position  { return ls.Token.Span.Start }
+func (ls *LetStatement) End() token.Position {
+	if ls.Value == nil && ls.Name != nil {
+		return ls.Name.End()
+	}
+	return endOf(ls.Value, ls.Token)
+}
 
 // return a string which give out --> "let (name of var) = (expression);"
 func (ls *LetStatement) String() string {
@@ -113,7 +155,9 @@ type ReturnStatement struct {
 	ReturnValue Expression
 }
 
-func (rs *ReturnStatement) statementNode() {}
+func (rs *ReturnStatement) statementNode()      {}
+func (rs *ReturnStatement) Pos() token.Position { return rs.Token.Span.Start }
+func (rs *ReturnStatement) End() token.Position { return endOf(rs.ReturnValue, rs.Token) }
 
 // function returns the token literal which in this case is --> return
 func (rs *ReturnStatement) TokenLiteral() string {
@@ -146,7 +190,9 @@ type ExpressionStatement struct {
 	Expression Expression
 }
 
-func (es *ExpressionStatement) statementNode() {}
+func (es *ExpressionStatement) statementNode()      {}
+func (es *ExpressionStatement) Pos() token.Position { return es.Token.Span.Start }
+func (es *ExpressionStatement) End() token.Position { return endOf(es.Expression, es.Token) }
 func (es *ExpressionStatement) TokenLiteral() string {
 	return es.Token.Literal
 }
@@ -178,7 +224,9 @@ type IntegerLiteral struct {
 	Value int64
 }
 
-func (il *IntegerLiteral) expressionNode() {}
+func (il *IntegerLiteral) expressionNode()     {}
+func (il *IntegerLiteral) Pos() token.Position { return il.Token.Span.Start }
+func (il *IntegerLiteral) End() token.Position { return il.Token.Span.End }
 func (il *IntegerLiteral) TokenLiteral() string {
 	return il.Token.Literal
 }
@@ -186,6 +234,30 @@ func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
 
+// node for big integer literals like 123n
+type BigIntLiteral struct {
+	Token token.Token
+	Value *big.Int
+}
+
+func (bl *BigIntLiteral) expressionNode()      {}
+func (bl *BigIntLiteral) TokenLiteral() string { return bl.Token.Literal }
+func (bl *BigIntLiteral) String() string       { return bl.Token.Literal }
+func (bl *BigIntLiteral) Pos() token.Position  { return bl.Token.Span.Start }
+func (bl *BigIntLiteral) End() token.Position  { return bl.Token.Span.End }
+
+// node for floating point literals like 3.14, .5 or 1e-9
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+func (fl *FloatLiteral) Pos() token.Position  { return fl.Token.Span.Start }
+func (fl *FloatLiteral) End() token.Position  { return fl.Token.Span.End }
+
 // prefix Expression
 // example := -3,-7 etc
 
@@ -198,6 +270,8 @@ type PrefixExpression struct {
 
 func (pe *PrefixExpression) expressionNode()      {}
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
+func (pe *PrefixExpression) Pos() token.Position  { return pe.Token.Span.Start }
+func (pe *PrefixExpression) End() token.Position  { return endOf(pe.Right, pe.Token) }
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -222,6 +296,13 @@ type InfixExpression struct {
 
 func (oe *InfixExpression) expressionNode()      {}
 func (oe *InfixExpression) TokenLiteral() string { return oe.Token.Literal }
+func (oe *InfixExpression) Pos() token.Position {
+	if oe.Left == nil {
+		return oe.Token.Span.Start
+	}
+	return oe.Left.Pos()
+}
+func (oe *InfixExpression) End() token.Position { return endOf(oe.Right, oe.Token) }
 func (oe *InfixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -241,6 +322,19 @@ type Boolean struct {
 func (b *Boolean) expressionNode()      {}
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
 func (b *Boolean) String() string       { return b.Token.Literal }
+func (b *Boolean) Pos() token.Position  { return b.Token.Span.Start }
+func (b *Boolean) End() token.Position  { return b.Token.Span.End }
+
+// the null literal
+type Null struct {
+	Token token.Token
+}
+
+func (n *Null) expressionNode()      {}
+func (n *Null) TokenLiteral() string { return n.Token.Literal }
+func (n *Null) String() string       { return n.Token.Literal }
+func (n *Null) Pos() token.Position  { return n.Token.Span.Start }
+func (n *Null) End() token.Position  { return n.Token.Span.End }
 
 // if and else block
 /*
@@ -256,6 +350,16 @@ type IfExpression struct {
 
 func (ie *IfExpression) expressionNode()      {}
 func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IfExpression) Pos() token.Position  { return ie.Token.Span.Start }
+func (ie *IfExpression) End() token.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+	if ie.Consequence != nil {
+		return ie.Consequence.End()
+	}
+	return endOf(ie.Condition, ie.Token)
+}
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -274,12 +378,23 @@ func (ie *IfExpression) String() string {
 
 // node for bolck statement in our ast
 type BlockStatement struct {
-	Token      token.Token
+	Token      token.Token // the '{' token
 	Statements []Statement
+	Rbrace     token.Token // the closing '}' token
 }
 
 func (bs *BlockStatement) statementNode()       {}
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BlockStatement) Pos() token.Position  { return bs.Token.Span.Start }
+func (bs *BlockStatement) End() token.Position {
+	if bs.Rbrace.Span.End.IsValid() {
+		return bs.Rbrace.Span.End
+	}
+	if len(bs.Statements) > 0 {
+		return bs.Statements[len(bs.Statements)-1].End()
+	}
+	return bs.Token.Span.End
+}
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 	for _, s := range bs.Statements {
@@ -302,10 +417,18 @@ type FunctionLiteral struct {
 	Token      token.Token // would be "fn"
 	Parameters []*Identifier
 	Body       *BlockStatement
+	Name       string // name it was bound to with let, empty for anonymous functions
 }
 
 func (fl *FunctionLiteral) expressionNode()      {}
 func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FunctionLiteral) Pos() token.Position  { return fl.Token.Span.Start }
+func (fl *FunctionLiteral) End() token.Position {
+	if fl.Body == nil {
+		return fl.Token.Span.End
+	}
+	return fl.Body.End()
+}
 
 // this --> function will give a string like tokenLiteral like fn(params(separated using ',')) body
 func (fl *FunctionLiteral) String() string {
@@ -334,10 +457,18 @@ type CallExpression struct {
 	Token     token.Token // would be "("
 	Function  Expression
 	Arguments []Expression
+	Rparen    token.Token // the closing ')'
 }
 
 func (ce *CallExpression) expressionNode()      {}
 func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+func (ce *CallExpression) Pos() token.Position {
+	if ce.Function == nil {
+		return ce.Token.Span.Start
+	}
+	return ce.Function.Pos()
+}
+func (ce *CallExpression) End() token.Position { return closingEnd(ce.Rparen, ce.Token) }
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 	args := []string{}
@@ -360,15 +491,51 @@ type StringLiteral struct {
 func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
 func (sl *StringLiteral) String() string       { return sl.Token.Literal }
+func (sl *StringLiteral) Pos() token.Position  { return sl.Token.Span.Start }
+func (sl *StringLiteral) End() token.Position  { return sl.Token.Span.End }
+
+// strings with embedded expressions like "total: ${sum} items".
+// Parts alternates between the text pieces (StringLiterals, at even indexes) and the embedded expressions,
+// starting and ending with text: "total: ", sum, " items"
+type InterpolatedString struct {
+	Token token.Token // the TEMPLATE_HEAD token
+	Parts []Expression
+	Tail  token.Token // the TEMPLATE_TAIL token
+}
+
+func (is *InterpolatedString) expressionNode()      {}
+func (is *InterpolatedString) TokenLiteral() string { return is.Token.Literal }
+func (is *InterpolatedString) Pos() token.Position  { return is.Token.Span.Start }
+func (is *InterpolatedString) End() token.Position  { return closingEnd(is.Tail, is.Token) }
+func (is *InterpolatedString) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("\"")
+	for i, part := range is.Parts {
+		if i%2 == 0 {
+			out.WriteString(part.String())
+			continue
+		}
+		out.WriteString("${")
+		out.WriteString(part.String())
+		out.WriteString("}")
+	}
+	out.WriteString("\"")
+
+	return out.String()
+}
 
 // adding arrays
 type ArrayLiteral struct {
 	Token    token.Token // would be [
 	Elements []Expression
+	Rbracket token.Token // the closing ]
 }
 
 func (al *ArrayLiteral) expressionNode()      {}
 func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+func (al *ArrayLiteral) Pos() token.Position  { return al.Token.Span.Start }
+func (al *ArrayLiteral) End() token.Position  { return closingEnd(al.Rbracket, al.Token) }
 func (al *ArrayLiteral) String() string {
 	var out bytes.Buffer
 	elements := []string{}
@@ -381,20 +548,55 @@ func (al *ArrayLiteral) String() string {
 	return out.String()
 }
 
+// tuples like #(1, "a"), fixed lists that can be used as hash keys
+type TupleLiteral struct {
+	Token    token.Token // the #( token
+	Elements []Expression
+	Rparen   token.Token // the closing )
+}
+
+func (tl *TupleLiteral) expressionNode()      {}
+func (tl *TupleLiteral) TokenLiteral() string { return tl.Token.Literal }
+func (tl *TupleLiteral) Pos() token.Position  { return tl.Token.Span.Start }
+func (tl *TupleLiteral) End() token.Position  { return closingEnd(tl.Rparen, tl.Token) }
+func (tl *TupleLiteral) String() string {
+	var out bytes.Buffer
+	elements := []string{}
+	for _, el := range tl.Elements {
+		elements = append(elements, el.String())
+	}
+	out.WriteString("#(")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString(")")
+	return out.String()
+}
+
 // node for indexing of array literals (implements expression node)
 type IndexExpression struct {
-	Token token.Token // would be [
-	Left  Expression
-	Index Expression
+	Token    token.Token // would be [, . for a.b, or ?[ and ?. for optional access
+	Left     Expression
+	Index    Expression
+	Rbracket token.Token // the closing ], or the name after . and ?.
+	Optional bool        // a?[i] or a?.b, gives null instead of indexing when a is null, and skips the rest of the chain
 }
 
 func (ie *IndexExpression) expressionNode()      {}
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+func (ie *IndexExpression) Pos() token.Position {
+	if ie.Left == nil {
+		return ie.Token.Span.Start
+	}
+	return ie.Left.Pos()
+}
+func (ie *IndexExpression) End() token.Position { return closingEnd(ie.Rbracket, ie.Token) }
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 
 	out.WriteString("(")
 	out.WriteString(ie.Left.String())
+	if ie.Optional {
+		out.WriteString("?")
+	}
 	out.WriteString("[")
 	out.WriteString(ie.Index.String())
 	out.WriteString("])")
@@ -407,17 +609,21 @@ func (ie *IndexExpression) String() string {
 
 // node for hash map in golang(implements Expression node)
 type HashLiteral struct {
-	Token token.Token // the '{' token
-	Pairs map[Expression]Expression
+	Token  token.Token // the '{' token
+	Pairs  map[Expression]Expression
+	Keys   []Expression // keys of Pairs in the order they appear in the source
+	Rbrace token.Token  // the closing '}' token
 }
 
 func (hl *HashLiteral) expressionNode()      {}
 func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) Pos() token.Position  { return hl.Token.Span.Start }
+func (hl *HashLiteral) End() token.Position  { return closingEnd(hl.Rbrace, hl.Token) }
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 	pairs := []string{}
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, key := range hl.Keys {
+		pairs = append(pairs, key.String()+":"+hl.Pairs[key].String())
 	}
 	out.WriteString("{")
 	out.WriteString(strings.Join(pairs, ", "))
@@ -425,6 +631,60 @@ func (hl *HashLiteral) String() string {
 	return out.String()
 }
 
+// node for break statement, leaves the innermost while loop
+type BreakStatement struct {
+	Token token.Token
+}
+
+func (bs *BreakStatement) statementNode()       {}
+func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+func (bs *BreakStatement) String() string       { return bs.Token.Literal + ";" }
+func (bs *BreakStatement) Pos() token.Position  { return bs.Token.Span.Start }
+func (bs *BreakStatement) End() token.Position  { return bs.Token.Span.End }
+
+// node for continue statement, skips to the next iteration of the innermost while loop
+type ContinueStatement struct {
+	Token token.Token
+}
+
+func (cs *ContinueStatement) statementNode()       {}
+func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+func (cs *ContinueStatement) String() string       { return cs.Token.Literal + ";" }
+func (cs *ContinueStatement) Pos() token.Position  { return cs.Token.Span.Start }
+func (cs *ContinueStatement) End() token.Position  { return cs.Token.Span.End }
+
+// assignment to an existing binding
+/*
+x = 5
+x += 1
+*/
+// node for assignment expressions (implements Expression node)
+type AssignExpression struct {
+	Token    token.Token // the =, +=, -=, *= or /= token
+	Target   Expression  // what gets assigned, an *Identifier
+	Operator string
+	Value    Expression
+}
+
+func (ae *AssignExpression) expressionNode()      {}
+func (ae *AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+func (ae *AssignExpression) Pos() token.Position {
+	if ae.Target == nil {
+		return ae.Token.Span.Start
+	}
+	return ae.Target.Pos()
+}
+func (ae *AssignExpression) End() token.Position { return endOf(ae.Value, ae.Token) }
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(ae.Target.String())
+	out.WriteString(" " + ae.Operator + " ")
+	out.WriteString(ae.Value.String())
+	out.WriteString(")")
+	return out.String()
+}
+
 // node for while statement
 type WhileStatement struct {
 	Token     token.Token
@@ -432,7 +692,14 @@ type WhileStatement struct {
 	Body      *BlockStatement
 }
 
-func (we *WhileStatement) statementNode() {}
+func (we *WhileStatement) statementNode()      {}
+func (we *WhileStatement) Pos() token.Position { return we.Token.Span.Start }
+func (we *WhileStatement) End() token.Position {
+	if we.Body == nil {
+		return endOf(we.Condition, we.Token)
+	}
+	return we.Body.End()
+}
 
 func (we *WhileStatement) TokenLiteral() string {
 	return we.Token.Literal
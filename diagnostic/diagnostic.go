@@ -0,0 +1,139 @@
+package diagnostic
+
+import (
+	"bytes"
+	"fmt"
+	"monkey/token"
+	"strconv"
+	"strings"
+)
+
+// how bad a diagnostic is
+type Severity int
+
+const (
+	Error Severity = iota
+	Warning
+	Note
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// stable identifier for each kind of diagnostic, so tools and tests don't have to match on messages
+type Code string
+
+const (
+	UnexpectedToken Code = "P0001" // the parser wanted a specific token and got another
+	NoPrefixParseFn Code = "P0002" // a token that can't start an expression
+	InvalidInteger  Code = "P0003" // an integer literal that doesn't fit in an int64
+	InvalidAssign   Code = "P0004" // the left side of an assignment is not something that can be assigned to
+	OutsideLoop     Code = "P0005" // break or continue that isn't inside a while loop
+	InvalidFloat    Code = "P0006" // a float literal that is out of range
+
+	IllegalCharacter    Code = "L0001" // a character that doesn't start any token
+	MalformedNumber     Code = "L0002" // a number literal like 0x, 1__0 or 12abc
+	UnterminatedString  Code = "L0003" // a string without its closing quote
+	InvalidEscape       Code = "L0004" // an unknown or malformed escape sequence in a string
+	UnterminatedComment Code = "L0005" // a /* comment without its closing */
+)
+
+// Diagnostic is a single problem found in the source
+type Diagnostic struct {
+	Severity Severity
+	Code     Code
+	Span     token.Span
+	Message  string
+	Expected []token.TokenType // tokens that would have been accepted, if known
+	Found    token.TokenType   // token that was found instead, if any
+	Hints    []string          // suggestions on how to fix the problem
+}
+
+// returns the diagnostic on one line in the form "file:line:column: message"
+func (d Diagnostic) String() string {
+	return d.Span.Start.String() + ": " + d.Message
+}
+
+// returns the diagnostic together with the source line it points at and a caret underline
+/*
+error[P0001]: expected next token to be ), got { instead
+ --> main.mok:3:10
+  |
+3 | if (x < 3 {
+  |           ^
+  = help: ...
+*/
+func (d Diagnostic) Render(source string) string {
+	var out bytes.Buffer
+
+	start := d.Span.Start
+	fmt.Fprintf(&out, "%s[%s]: %s\n", d.Severity, d.Code, d.Message)
+
+	if !start.IsValid() {
+		for _, hint := range d.Hints {
+			fmt.Fprintf(&out, "  = help: %s\n", hint)
+		}
+		return out.String()
+	}
+
+	lineNo := strconv.Itoa(start.Line)
+	gutter := strings.Repeat(" ", len(lineNo))
+
+	fmt.Fprintf(&out, "%s--> %s\n", gutter, start)
+
+	line, ok := sourceLine(source, start.Line)
+	if ok {
+		fmt.Fprintf(&out, "%s |\n", gutter)
+		fmt.Fprintf(&out, "%s | %s\n", lineNo, line)
+		fmt.Fprintf(&out, "%s | %s\n", gutter, underline(line, start, d.Span.End))
+	}
+
+	for _, hint := range d.Hints {
+		fmt.Fprintf(&out, "%s = help: %s\n", gutter, hint)
+	}
+
+	return out.String()
+}
+
+// returns the n-th (1 based) line of source without its line ending
+func sourceLine(source string, n int) (string, bool) {
+	lines := strings.Split(source, "\n")
+	if n < 1 || n > len(lines) {
+		return "", false
+	}
+	return strings.TrimRight(lines[n-1], "\r"), true
+}
+
+// builds the "   ^^^" line below the source line, tabs are copied so the carets line up
+func underline(line string, start, end token.Position) string {
+	var out bytes.Buffer
+
+	col := 1
+	for _, ch := range line {
+		if col >= start.Column {
+			break
+		}
+		if ch == '\t' {
+			out.WriteByte('\t')
+		} else {
+			out.WriteByte(' ')
+		}
+		col++
+	}
+
+	width := 1
+	if end.Line == start.Line && end.Column > start.Column {
+		width = end.Column - start.Column
+	}
+	out.WriteString(strings.Repeat("^", width))
+
+	return out.String()
+}
@@ -0,0 +1,58 @@
+package diagnostic
+
+import (
+	"monkey/token"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	source := "let x = 1;\n\tlet y = (x + 2;\n"
+
+	d := Diagnostic{
+		Severity: Error,
+		Code:     UnexpectedToken,
+		Span: token.Span{
+			Start: token.Position{File: "main.mok", Line: 2, Column: 16},
+			End:   token.Position{File: "main.mok", Line: 2, Column: 17},
+		},
+		Message: "expected next token to be ), got ; instead",
+		Hints:   []string{"add the missing )"},
+	}
+
+	expected := "error[P0001]: expected next token to be ), got ; instead\n" +
+		" --> main.mok:2:16\n" +
+		"  |\n" +
+		"2 | \tlet y = (x + 2;\n" +
+		"  | \t              ^\n" +
+		"  = help: add the missing )\n"
+
+	if got := d.Render(source); got != expected {
+		t.Errorf("Render wrong.\nexpected=%q\ngot=     %q", expected, got)
+	}
+
+	if got := d.String(); got != "main.mok:2:16: expected next token to be ), got ; instead" {
+		t.Errorf("String wrong. got=%q", got)
+	}
+}
+
+func TestRenderUnderlinesWholeSpan(t *testing.T) {
+	d := Diagnostic{
+		Severity: Warning,
+		Code:     InvalidInteger,
+		Span: token.Span{
+			Start: token.Position{Line: 1, Column: 5},
+			End:   token.Position{Line: 1, Column: 9},
+		},
+		Message: "bad",
+	}
+
+	expected := "warning[P0003]: bad\n" +
+		" --> 1:5\n" +
+		"  |\n" +
+		"1 | let 1234;\n" +
+		"  |     ^^^^\n"
+
+	if got := d.Render("let 1234;"); got != expected {
+		t.Errorf("Render wrong.\nexpected=%q\ngot=     %q", expected, got)
+	}
+}
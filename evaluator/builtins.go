@@ -2,8 +2,10 @@ package evaluator
 
 import (
 	"fmt"
+	"math"
 	"monkey/object"
-	// "unicode/utf8"
+	"strconv"
+	"unicode/utf8"
 )
 
 var builtins = map[string]*object.Builtin{
@@ -15,9 +17,12 @@ var builtins = map[string]*object.Builtin{
 
 			switch arg := args[0].(type) {
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				// counts characters, not bytes, len("größe") is 5
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.Tuple:
+				return &object.Integer{Value: int64(len(arg.Elements))}
 			default:
 				return newError("argument to `len` not supported, got %s", args[0].Type())
 			}
@@ -100,6 +105,85 @@ var builtins = map[string]*object.Builtin{
 			return &object.Array{Elements: newELement}
 		},
 	},
+	"int": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				return arg
+			case *object.BigInt:
+				if !arg.Value.IsInt64() {
+					return newError("argument to `int` out of INTEGER range, got %s", arg.Inspect())
+				}
+				return &object.Integer{Value: arg.Value.Int64()}
+			case *object.Float:
+				return floatToInteger("int", arg.Value)
+			case *object.Boolean:
+				if arg.Value {
+					return &object.Integer{Value: 1}
+				}
+				return &object.Integer{Value: 0}
+			case *object.String:
+				value, err := strconv.ParseInt(arg.Value, 0, 64)
+				if err != nil {
+					return newError("could not convert %q to INTEGER", arg.Value)
+				}
+				return &object.Integer{Value: value}
+			default:
+				return newError("argument to `int` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+	"float": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer, *object.BigInt:
+				return &object.Float{Value: object.FloatValue(arg)}
+			case *object.Float:
+				return arg
+			case *object.String:
+				value, err := strconv.ParseFloat(arg.Value, 64)
+				if err != nil {
+					return newError("could not convert %q to FLOAT", arg.Value)
+				}
+				return &object.Float{Value: value}
+			default:
+				return newError("argument to `float` not supported, got %s", args[0].Type())
+			}
+		},
+	},
+	"floor": roundingBuiltin("floor", math.Floor),
+	"ceil":  roundingBuiltin("ceil", math.Ceil),
+	"round": roundingBuiltin("round", math.Round),
+	"sqrt": &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			if !object.IsNumber(args[0]) {
+				return newError("argument to `sqrt` must be INTEGER or FLOAT, got %s", args[0].Type())
+			}
+
+			value := object.FloatValue(args[0])
+			if value < 0 {
+				return newError("argument to `sqrt` must not be negative, got %s", args[0].Inspect())
+			}
+			return &object.Float{Value: math.Sqrt(value)}
+		},
+	},
+	"keys":   hashBuiltin("keys", func(pair object.HashPair) object.Object { return pair.Key }),
+	"values": hashBuiltin("values", func(pair object.HashPair) object.Object { return pair.Value }),
+	"entries": hashBuiltin("entries", func(pair object.HashPair) object.Object {
+		return &object.Array{Elements: []object.Object{pair.Key, pair.Value}}
+	}),
 	"puts": &object.Builtin{
 		Fn: func(args ...object.Object) object.Object {
 			for _, arg := range args {
@@ -109,3 +193,53 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 }
+
+// floor, ceil and round: integers are returned as they are, floats are rounded with fn and turned into integers
+func roundingBuiltin(name string, fn func(float64) float64) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer, *object.BigInt:
+				return arg
+			case *object.Float:
+				return floatToInteger(name, fn(arg.Value))
+			default:
+				return newError("argument to `%s` must be INTEGER or FLOAT, got %s", name, args[0].Type())
+			}
+		},
+	}
+}
+
+// keys, values and entries: an array with fn applied to every pair of a hash, in insertion order
+func hashBuiltin(name string, fn func(object.HashPair) object.Object) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			hash, ok := args[0].(*object.Hash)
+			if !ok {
+				return newError("argument to `%s` must be HASH, got %s", name, args[0].Type())
+			}
+
+			elements := make([]object.Object, 0, hash.Len())
+			for _, pair := range hash.Pairs() {
+				elements = append(elements, fn(pair))
+			}
+			return &object.Array{Elements: elements}
+		},
+	}
+}
+
+// drops the fraction of f, NaN, infinities and values outside the int64 range can't be converted
+func floatToInteger(name string, f float64) object.Object {
+	if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return newError("argument to `%s` out of INTEGER range, got %s", name, (&object.Float{Value: f}).Inspect())
+	}
+	return &object.Integer{Value: int64(f)}
+}
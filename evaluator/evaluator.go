@@ -2,8 +2,14 @@ package evaluator
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
 	"monkey/ast"
 	"monkey/object"
+	"monkey/token"
 )
 
 func Eval(node ast.Node, env *object.Environment) object.Object {
@@ -13,18 +19,18 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalProgram(node, env)
 
 	case *ast.Identifier:
-		return evalIdentifier(node, env)
+		return withPos(evalIdentifier(node, env), node.Pos())
 
 	case *ast.LetStatement:
 		val := Eval(node.Value, env)
-		if isError(val) {
+		if isControl(val) {
 			return val
 		}
 		env.Set(node.Name.Value, val)
 
 	case *ast.ReturnStatement:
 		val := Eval(node.ReturnValue, env)
-		if isError(val) {
+		if isControl(val) {
 			return val
 		}
 		return &object.ReturnValue{Value: val}
@@ -35,29 +41,45 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
 
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
+	case *ast.BigIntLiteral:
+		return &object.BigInt{Value: node.Value}
+
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
-		if isError(right) {
+		if isControl(right) {
 			return right
 		}
-		return evalPrefixExpression(node.Operator, right)
+		return withPos(evalPrefixExpression(node.Operator, right), node.Pos())
 
 	case *ast.InfixExpression:
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node, env)
+		}
+		if node.Operator == "??" {
+			return evalNullishExpression(node, env)
+		}
+
 		left := Eval(node.Left, env)
-		if isError(left) {
+		if isControl(left) {
 			return left
 		}
 
 		right := Eval(node.Right, env)
-		if isError(right) {
+		if isControl(right) {
 			return right
 		}
 
-		return evalInfixExpression(node.Operator, left, right)
+		return withPos(evalInfixExpression(node.Operator, left, right), node.Token.Span.Start)
 
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 
+	case *ast.Null:
+		return NULL
+
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
 
@@ -68,49 +90,46 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		params := node.Parameters
 		body := node.Body
 
-		return &object.Function{Parameters: params, Body: body, Env: env}
+		return &object.Function{Parameters: params, Body: body, Env: env, Name: node.Name}
 
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
-		if isError(function) {
-			return function
-		}
-
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-
-		return applyFunction(function, args)
+		result, _ := evalChain(node, env)
+		return result
 
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 
+	case *ast.InterpolatedString:
+		return evalInterpolatedString(node, env)
+
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
-		if len(elements) == 1 && isError(elements[0]) {
+		if len(elements) == 1 && isControl(elements[0]) {
 			return elements[0]
 		}
 		return &object.Array{Elements: elements}
 
-	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
-			return left
-		}
-
-		index := Eval(node.Index, env)
-		if isError(index) {
-			return index
-		}
+	case *ast.TupleLiteral:
+		return evalTupleLiteral(node, env)
 
-		return evalIndexExpression(left, index)
+	case *ast.IndexExpression:
+		result, _ := evalChain(node, env)
+		return result
 
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
 
 	case *ast.WhileStatement:
 		return evalWhileExpression(node, env)
+
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+
+	case *ast.BreakStatement:
+		return BREAK
+
+	case *ast.ContinueStatement:
+		return CONTINUE
 	}
 
 	return nil
@@ -135,8 +154,23 @@ var (
 	TRUE  = &object.Boolean{Value: true}
 	FALSE = &object.Boolean{Value: false}
 	NULL  = &object.Null{}
+
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
+// when true, if and while bodies run directly in the environment around them so a let inside the
+// block stays visible after it. Only for old scripts that rely on that, off by default
+var LeakyBlockScope = false
+
+// when true, conditions of if and while and the operand of ! have to be booleans, anything else is an error
+// instead of going through the truthiness rules of isTruthy
+var StrictTruthiness = false
+
+// when true, + - * ** and unary - on integers report an error when the result doesn't fit in 64 bits
+// instead of silently wrapping around
+var CheckedArithmetic = false
+
 func nativeBoolToBooleanObject(input bool) *object.Boolean {
 	if input {
 		return TRUE
@@ -151,6 +185,8 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 		return evalBangOperatorExpression(right)
 	case "-":
 		return evalMinusPrefixOperatorExpression(right)
+	case "~":
+		return evalTildePrefixOperatorExpression(right)
 	default:
 		return newError("unknown operator: %s%s", operator, right.Type())
 	}
@@ -158,40 +194,60 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 
 // for ! prefix expressions
 func evalBangOperatorExpression(right object.Object) object.Object {
-	switch right {
-	case TRUE:
-		return FALSE
-	case FALSE:
-		return TRUE
-	case NULL:
-		return TRUE
-	default:
-		return FALSE
+	if StrictTruthiness && right.Type() != object.BOOLEA_OBJ {
+		return newError("unknown operator: !%s", right.Type())
 	}
+	return nativeBoolToBooleanObject(!isTruthy(right))
 }
 
 // for - prefix expressions
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	switch right := right.(type) {
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	case *object.BigInt:
+		return &object.BigInt{Value: new(big.Int).Neg(right.Value)}
+	}
 	if right.Type() != object.INTEGER_OBJ {
 		return newError("unknown operator: -%s", right.Type())
 	}
 
 	value := right.(*object.Integer).Value
+	if CheckedArithmetic && value == math.MinInt64 {
+		return newError("integer overflow: -(%d)", value)
+	}
 	return &object.Integer{Value: -value}
 }
 
+// for ~ prefix expressions, flips every bit of an integer
+func evalTildePrefixOperatorExpression(right object.Object) object.Object {
+	if b, ok := right.(*object.BigInt); ok {
+		return &object.BigInt{Value: new(big.Int).Not(b.Value)}
+	}
+	if right.Type() != object.INTEGER_OBJ {
+		return newError("unknown operator: ~%s", right.Type())
+	}
+
+	value := right.(*object.Integer).Value
+	return &object.Integer{Value: ^value}
+}
+
 // for infix expressions
 func evalInfixExpression(operator string, left object.Object, right object.Object) object.Object {
 
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
+	case object.IsInteger(left) && object.IsInteger(right):
+		return evalBigIntInfixExpression(operator, left, right)
+	case object.IsNumber(left) && object.IsNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
 	case operator == "==":
-		return nativeBoolToBooleanObject(left == right)
+		return nativeBoolToBooleanObject(object.Equal(left, right))
 	case operator == "!=":
-		return nativeBoolToBooleanObject(left != right)
+		return nativeBoolToBooleanObject(!object.Equal(left, right))
 	case left.Type() != right.Type():
 		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	default:
@@ -200,6 +256,46 @@ func evalInfixExpression(operator string, left object.Object, right object.Objec
 	}
 }
 
+// && and || only evaluate the right side when the left one doesn't decide the result, and give back
+// whichever operand decided it: 0 || "x" is "x", 0 && "x" is 0
+func evalLogicalExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isControl(left) {
+		return left
+	}
+	if err := checkCondition(left, node.Left); err != nil {
+		return err
+	}
+
+	if isTruthy(left) == (node.Operator == "||") {
+		return left
+	}
+
+	right := Eval(node.Right, env)
+	if isControl(right) {
+		return right
+	}
+	if err := checkCondition(right, node.Right); err != nil {
+		return err
+	}
+
+	return right
+}
+
+// ?? gives back the left side unless it is null, the right side is only evaluated when it is.
+// unlike || it keeps falsy values like 0 or "": 0 ?? 5 is 0
+func evalNullishExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isControl(left) {
+		return left
+	}
+	if left != NULL {
+		return left
+	}
+
+	return Eval(node.Right, env)
+}
+
 // returns object after infix operation between integers
 func evalIntegerInfixExpression(operator string, left object.Object, right object.Object) object.Object {
 	leftVal := left.(*object.Integer).Value
@@ -207,17 +303,67 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 
 	switch operator {
 	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
+		sum := leftVal + rightVal
+		if CheckedArithmetic && (leftVal^sum)&(rightVal^sum) < 0 {
+			return overflowError(leftVal, operator, rightVal)
+		}
+		return &object.Integer{Value: sum}
 	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
+		diff := leftVal - rightVal
+		if CheckedArithmetic && (leftVal^rightVal)&(leftVal^diff) < 0 {
+			return overflowError(leftVal, operator, rightVal)
+		}
+		return &object.Integer{Value: diff}
 	case "*":
+		if CheckedArithmetic && mulOverflows(leftVal, rightVal) {
+			return overflowError(leftVal, operator, rightVal)
+		}
 		return &object.Integer{Value: leftVal * rightVal}
 	case "/":
+		if rightVal == 0 {
+			return newError("division by zero: %d / 0", leftVal)
+		}
+		if CheckedArithmetic && leftVal == math.MinInt64 && rightVal == -1 {
+			return overflowError(leftVal, operator, rightVal)
+		}
 		return &object.Integer{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("modulo by zero: %d %% 0", leftVal)
+		}
+		return &object.Integer{Value: leftVal % rightVal}
+	case "**":
+		// 2 ** -1 is 0.5, only a float can hold that
+		if rightVal < 0 {
+			return &object.Float{Value: math.Pow(float64(leftVal), float64(rightVal))}
+		}
+		result, overflow := intPow(leftVal, rightVal)
+		if CheckedArithmetic && overflow {
+			return overflowError(leftVal, operator, rightVal)
+		}
+		return &object.Integer{Value: result}
+	case "&":
+		return &object.Integer{Value: leftVal & rightVal}
+	case "|":
+		return &object.Integer{Value: leftVal | rightVal}
+	case "^":
+		return &object.Integer{Value: leftVal ^ rightVal}
+	case "<<", ">>":
+		if rightVal < 0 {
+			return newError("negative shift count: %d %s %d", leftVal, operator, rightVal)
+		}
+		if operator == "<<" {
+			return &object.Integer{Value: leftVal << uint64(rightVal)}
+		}
+		return &object.Integer{Value: leftVal >> uint64(rightVal)}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	case "==":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "!=":
@@ -227,6 +373,183 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 	}
 }
 
+// big integers with more bits than this are an error, so 2n ** 1000000000 can't eat all the memory
+const maxBigIntBits = 1 << 20
+
+// returns object after infix operation between two integers where at least one is a big integer, the result is
+// always a big integer
+func evalBigIntInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal, _ := object.BigIntValue(left)
+	rightVal, _ := object.BigIntValue(right)
+	result := new(big.Int)
+
+	switch operator {
+	case "+":
+		result.Add(leftVal, rightVal)
+	case "-":
+		result.Sub(leftVal, rightVal)
+	case "*":
+		result.Mul(leftVal, rightVal)
+	case "/":
+		if rightVal.Sign() == 0 {
+			return newError("division by zero: %s / %s", left.Inspect(), right.Inspect())
+		}
+		result.Quo(leftVal, rightVal)
+	case "%":
+		if rightVal.Sign() == 0 {
+			return newError("modulo by zero: %s %% %s", left.Inspect(), right.Inspect())
+		}
+		result.Rem(leftVal, rightVal)
+	case "**":
+		if rightVal.Sign() < 0 {
+			return &object.Float{Value: math.Pow(object.FloatValue(left), object.FloatValue(right))}
+		}
+		// the result has at least (bits of base - 1) * exponent + 1 bits, 0, 1 and -1 stay small
+		if new(big.Int).Abs(leftVal).Cmp(big.NewInt(1)) > 0 {
+			minBits := new(big.Int).Mul(big.NewInt(int64(leftVal.BitLen()-1)), rightVal)
+			if minBits.Cmp(big.NewInt(maxBigIntBits)) >= 0 {
+				return bigIntTooLargeError(operator)
+			}
+		}
+		result.Exp(leftVal, rightVal, nil)
+	case "&":
+		result.And(leftVal, rightVal)
+	case "|":
+		result.Or(leftVal, rightVal)
+	case "^":
+		result.Xor(leftVal, rightVal)
+	case "<<", ">>":
+		if rightVal.Sign() < 0 {
+			return newError("negative shift count: %s %s %s", left.Inspect(), operator, right.Inspect())
+		}
+		if !rightVal.IsInt64() || rightVal.Int64() > math.MaxInt32 {
+			return newError("shift count too large: %s %s %s", left.Inspect(), operator, right.Inspect())
+		}
+		if operator == "<<" {
+			if leftVal.Sign() != 0 && int64(leftVal.BitLen())+rightVal.Int64() > maxBigIntBits {
+				return bigIntTooLargeError(operator)
+			}
+			result.Lsh(leftVal, uint(rightVal.Int64()))
+		} else {
+			result.Rsh(leftVal, uint(rightVal.Int64()))
+		}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) <= 0)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) >= 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+	if result.BitLen() > maxBigIntBits {
+		return bigIntTooLargeError(operator)
+	}
+	return &object.BigInt{Value: result}
+}
+
+// the operands are left out of the message, they can be hundreds of thousands of digits long
+func bigIntTooLargeError(operator string) *object.Error {
+	return newError("big integer too large: result of %s has more than %d bits", operator, maxBigIntBits)
+}
+
+// returns object after infix operation between two numbers where at least one is a float, the other one is promoted
+func evalFloatInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal := object.FloatValue(left)
+	rightVal := object.FloatValue(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero: %s / %s", left.Inspect(), right.Inspect())
+		}
+		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("modulo by zero: %s %% %s", left.Inspect(), right.Inspect())
+		}
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	case "**":
+		return &object.Float{Value: math.Pow(leftVal, rightVal)}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// base ** exp by repeated squaring, exp must not be negative.
+// the second result tells if the real value didn't fit and the first one has wrapped around
+func intPow(base, exp int64) (int64, bool) {
+	result := int64(1)
+	overflow := false
+	for exp > 0 {
+		if exp&1 == 1 {
+			overflow = overflow || mulOverflows(result, base)
+			result *= base
+		}
+		exp >>= 1
+		if exp > 0 {
+			overflow = overflow || mulOverflows(base, base)
+			base *= base
+		}
+	}
+	return result, overflow
+}
+
+// reports whether a * b doesn't fit in an int64
+func mulOverflows(a, b int64) bool {
+	if a == 0 || b == 0 {
+		return false
+	}
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return true
+	}
+	return (a*b)/b != a
+}
+
+func overflowError(left int64, operator string, right int64) *object.Error {
+	return newError("integer overflow: %d %s %d", left, operator, right)
+}
+
+// returns the string with every embedded expression replaced by the Inspect of its value
+func evalInterpolatedString(node *ast.InterpolatedString, env *object.Environment) object.Object {
+	var out strings.Builder
+
+	for _, part := range node.Parts {
+		evaluated := Eval(part, env)
+		if isControl(evaluated) {
+			return evaluated
+		}
+		out.WriteString(evaluated.Inspect())
+	}
+
+	return &object.String{Value: out.String()}
+}
+
 // returns object after infix operation between String
 func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
 	leftVal := left.(*object.String).Value
@@ -243,6 +566,10 @@ func evalStringInfixExpression(operator string, left, right object.Object) objec
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
 		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	default:
 		return newError("unknown operator: %s %s %s",
 			left.Type(), operator, right.Type())
@@ -253,31 +580,57 @@ func evalStringInfixExpression(operator string, left, right object.Object) objec
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
 
-	if isError(condition) {
+	if isControl(condition) {
 		return condition
 	}
+	if err := checkCondition(condition, ie.Condition); err != nil {
+		return err
+	}
 	if isTruthy(condition) {
-		return Eval(ie.Consequence, env)
+		return evalBlockStatement(ie.Consequence, blockEnvironment(env))
 	} else if ie.Alternative != nil {
-		return Eval(ie.Alternative, env)
+		return evalBlockStatement(ie.Alternative, blockEnvironment(env))
 	} else {
 		return NULL
 	}
 }
 
+// truthiness rules: false, null, 0, "", [] and {} are falsy, every other value is truthy
 func isTruthy(obj object.Object) bool {
-	switch obj {
-	case NULL:
-		return false
-	case TRUE:
-		return true
-	case FALSE:
+	switch obj := obj.(type) {
+	case *object.Boolean:
+		return obj.Value
+	case *object.Null:
 		return false
+	case *object.Integer:
+		return obj.Value != 0
+	case *object.Float:
+		return obj.Value != 0
+	case *object.BigInt:
+		return obj.Value.Sign() != 0
+	case *object.String:
+		return obj.Value != ""
+	case *object.Array:
+		return len(obj.Elements) != 0
+	case *object.Hash:
+		return obj.Len() != 0
+	case *object.Tuple:
+		return len(obj.Elements) != 0
 	default:
-		return false
+		return true
 	}
 }
 
+// in strict mode a condition has to be a boolean, returns the error for the condition node otherwise
+func checkCondition(condition object.Object, node ast.Node) *object.Error {
+	if !StrictTruthiness || condition.Type() == object.BOOLEA_OBJ {
+		return nil
+	}
+	err := newError("non-boolean condition: %s", condition.Type())
+	err.Pos = node.Pos()
+	return err
+}
+
 // to solve nested return nested return statment
 func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	var result object.Object
@@ -293,15 +646,20 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	return result
 }
 
+// returns the environment for the body of an if or while, lets inside it are local to the block
+func blockEnvironment(env *object.Environment) *object.Environment {
+	if LeakyBlockScope {
+		return env
+	}
+	return object.NewEnclosedEnvironment(env)
+}
+
 func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
 	var result object.Object
 	for _, statement := range block.Statements {
 		result = Eval(statement, env)
-		if result != nil {
-			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
-				return result
-			}
+		if isControl(result) {
+			return result
 		}
 	}
 	return result
@@ -313,6 +671,23 @@ func newError(format string, a ...interface{}) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
 
+// records where an error happened, errors that already know their position keep it
+func withPos(obj object.Object, pos token.Position) object.Object {
+	if err, ok := obj.(*object.Error); ok && !err.Pos.IsValid() {
+		err.Pos = pos
+	}
+	return obj
+}
+
+// records that err passed out of a call to fn made at pos
+func pushFrame(err *object.Error, fn *object.Function, pos token.Position) {
+	name := fn.Name
+	if name == "" {
+		name = "<anonymous>"
+	}
+	err.Stack = append(err.Stack, object.Frame{Function: name, Pos: pos})
+}
+
 func isError(obj object.Object) bool {
 	if obj != nil {
 		return obj.Type() == object.ERROR_OBJ
@@ -321,6 +696,19 @@ func isError(obj object.Object) bool {
 	return false
 }
 
+// reports whether obj has to be passed straight up instead of being used as a value: an error, a return
+// value, break or continue. let x = if (c) { break; } leaves the loop rather than binding break to x
+func isControl(obj object.Object) bool {
+	if obj == nil {
+		return false
+	}
+	switch obj.Type() {
+	case object.ERROR_OBJ, object.RETURN_VALUE_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+		return true
+	}
+	return false
+}
+
 // Binding vars with value
 func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
 	val, ok := env.Get(node.Value)
@@ -335,6 +723,108 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 	return newError("identifier not found: " + node.Value)
 }
 
+// x = 5 or x += 5, the variable has to exist already in this or an enclosing environment
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	if target, ok := node.Target.(*ast.IndexExpression); ok {
+		return evalIndexAssignExpression(node, target, env)
+	}
+	ident := node.Target.(*ast.Identifier)
+
+	var current object.Object
+	if node.Operator != "=" {
+		val, ok := env.Get(ident.Value)
+		if !ok {
+			return withPos(newError("identifier not found: "+ident.Value), ident.Pos())
+		}
+		current = val
+	}
+
+	val := Eval(node.Value, env)
+	if isControl(val) {
+		return val
+	}
+
+	if current != nil {
+		operator := strings.TrimSuffix(node.Operator, "=")
+		val = withPos(evalInfixExpression(operator, current, val), node.Token.Span.Start)
+		if isControl(val) {
+			return val
+		}
+	}
+
+	if _, ok := env.Assign(ident.Value, val); !ok {
+		return withPos(newError("assignment to undeclared identifier: %s", ident.Value), ident.Pos())
+	}
+
+	return val
+}
+
+// a[i] = v and h["k"] = v, the array or hash is changed in place so every variable holding it sees the change
+func evalIndexAssignExpression(node *ast.AssignExpression, target *ast.IndexExpression, env *object.Environment) object.Object {
+	left := Eval(target.Left, env)
+	if isControl(left) {
+		return left
+	}
+
+	index := Eval(target.Index, env)
+	if isControl(index) {
+		return index
+	}
+
+	if err := checkIndexAssign(left, index); err != nil {
+		return withPos(err, target.Index.Pos())
+	}
+
+	var current object.Object
+	if node.Operator != "=" {
+		current = evalIndexExpression(left, index)
+	}
+
+	val := Eval(node.Value, env)
+	if isControl(val) {
+		return val
+	}
+
+	if current != nil {
+		operator := strings.TrimSuffix(node.Operator, "=")
+		val = withPos(evalInfixExpression(operator, current, val), node.Token.Span.Start)
+		if isControl(val) {
+			return val
+		}
+	}
+
+	switch left := left.(type) {
+	case *object.Array:
+		left.Elements[index.(*object.Integer).Value] = val
+	case *object.Hash:
+		left.Set(index.(object.Hashable), val)
+	}
+
+	return val
+}
+
+// returns an error when left[index] can't be assigned to: left isn't an array or hash,
+// the index of an array is out of range or the key of a hash isn't hashable
+func checkIndexAssign(left, index object.Object) *object.Error {
+	switch left := left.(type) {
+	case *object.Array:
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError("array index must be INTEGER, got %s", index.Type())
+		}
+		if idx.Value < 0 || idx.Value >= int64(len(left.Elements)) {
+			return newError("index out of range: %d (array length %d)", idx.Value, len(left.Elements))
+		}
+	case *object.Hash:
+		if _, ok := index.(object.Hashable); !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+	default:
+		return newError("index assignment not supported: %s", left.Type())
+	}
+	return nil
+}
+
 // to eval the function arguments
 func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
 	var result []object.Object
@@ -342,7 +832,7 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 	for _, e := range exps {
 		evaluated := Eval(e, env)
 
-		if isError(evaluated) {
+		if isControl(evaluated) {
 			return []object.Object{evaluated}
 		}
 
@@ -368,6 +858,96 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 
 }
 
+// calls function with args, errors get the position of the call and, for functions written in the
+// language, a frame in their stack trace
+func callFunction(function object.Object, args []object.Object, node *ast.CallExpression) object.Object {
+	result := withPos(applyFunction(function, args), node.Pos())
+	if fn, ok := function.(*object.Function); ok && isError(result) {
+		pushFrame(result.(*object.Error), fn, node.Pos())
+	}
+	return result
+}
+
+// evaluates a chain of calls, indexes and field accesses like a?.b.c(1)[0]. once a ?. or ?[ finds null
+// the rest of the chain is skipped and the whole chain is null, skipped says that happened.
+// parentheses don't end a chain, (a?.b).c is null as well when a is null
+func evalChain(node ast.Expression, env *object.Environment) (result object.Object, skipped bool) {
+	switch node := node.(type) {
+	case *ast.CallExpression:
+		if callee, ok := node.Function.(*ast.IndexExpression); ok && isDotAccess(callee) {
+			return evalMethodCall(node, callee, env)
+		}
+
+		function, skipped := evalChain(node.Function, env)
+		if skipped || isControl(function) {
+			return function, skipped
+		}
+
+		args := evalExpressions(node.Arguments, env)
+		if len(args) == 1 && isControl(args[0]) {
+			return args[0], false
+		}
+
+		return callFunction(function, args, node), false
+
+	case *ast.IndexExpression:
+		left, skipped := evalChain(node.Left, env)
+		if skipped || isControl(left) {
+			return left, skipped
+		}
+		if node.Optional && left == NULL {
+			return NULL, true
+		}
+		if isDotAccess(node) && left.Type() != object.HASH_OBJ {
+			return withPos(newError("field access not supported: %s.%s", left.Type(), node.Index.String()), node.Index.Pos()), false
+		}
+
+		index := Eval(node.Index, env)
+		if isControl(index) {
+			return index, false
+		}
+
+		return withPos(evalIndexExpression(left, index), node.Token.Span.Start), false
+	}
+	return Eval(node, env), false
+}
+
+// a.b and a?.b, as opposed to a["b"]
+func isDotAccess(node *ast.IndexExpression) bool {
+	return node.Token.Type == token.DOT || node.Token.Type == token.OPTIONAL_DOT
+}
+
+// value.name(args): a hash with a name key calls the function stored there, anything else
+// calls the method called name for its type with value as the first argument
+// the receiver is part of the chain, so a?.b.name() is null when a is
+func evalMethodCall(node *ast.CallExpression, callee *ast.IndexExpression, env *object.Environment) (object.Object, bool) {
+	receiver, skipped := evalChain(callee.Left, env)
+	if skipped || isControl(receiver) {
+		return receiver, skipped
+	}
+	if callee.Optional && receiver == NULL {
+		return NULL, true
+	}
+
+	args := evalExpressions(node.Arguments, env)
+	if len(args) == 1 && isControl(args[0]) {
+		return args[0], false
+	}
+
+	name := callee.Index.(*ast.StringLiteral).Value
+	if hash, ok := receiver.(*object.Hash); ok {
+		if function, ok := hash.Get(&object.String{Value: name}); ok {
+			return callFunction(function, args, node), false
+		}
+	}
+
+	method, ok := methods[receiver.Type()][name]
+	if !ok {
+		return withPos(newError("unknown method: %s.%s", receiver.Type(), name), callee.Index.Pos()), false
+	}
+	return callFunction(method, append([]object.Object{receiver}, args...), node), false
+}
+
 // for copying old mapping to newer env mapping
 func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
 	env := object.NewEnclosedEnvironment(fn.Env)
@@ -393,6 +973,10 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
+	case left.Type() == object.TUPLE_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalArrayIndexExpression(&object.Array{Elements: left.(*object.Tuple).Elements}, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
 	default:
@@ -413,26 +997,60 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 	return arrayObject.Elements[idx]
 }
 
+// returns the character at the index as a string, indexes count characters rather than bytes
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	value := str.(*object.String).Value
+	idx := index.(*object.Integer).Value
+
+	if idx < 0 {
+		return NULL
+	}
+
+	// walks to the character instead of converting the whole string to runes on every index
+	for i := int64(0); len(value) > 0; i++ {
+		ch, size := utf8.DecodeRuneInString(value)
+		if i == idx {
+			return &object.String{Value: string(ch)}
+		}
+		value = value[size:]
+	}
+	return NULL
+}
+
+// tuples may only hold values that can be hash keys themselves, that keeps them usable as one
+func evalTupleLiteral(node *ast.TupleLiteral, env *object.Environment) object.Object {
+	elements := evalExpressions(node.Elements, env)
+	if len(elements) == 1 && isControl(elements[0]) {
+		return elements[0]
+	}
+
+	for i, element := range elements {
+		if _, ok := element.(object.Hashable); !ok {
+			return withPos(newError("unusable as tuple element: %s", element.Type()), node.Elements[i].Pos())
+		}
+	}
+	return &object.Tuple{Elements: elements}
+}
+
 // hash implmentation
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
-	for keyNode, valueNode := range node.Pairs {
+	hash := object.NewHash()
+	for _, keyNode := range node.Keys {
 		key := Eval(keyNode, env)
-		if isError(key) {
+		if isControl(key) {
 			return key
 		}
 		hashKey, ok := key.(object.Hashable)
 		if !ok {
-			return newError("unusable as hash key: %s", key.Type())
+			return withPos(newError("unusable as hash key: %s", key.Type()), keyNode.Pos())
 		}
-		value := Eval(valueNode, env)
-		if isError(value) {
+		value := Eval(node.Pairs[keyNode], env)
+		if isControl(value) {
 			return value
 		}
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
+		hash.Set(hashKey, value)
 	}
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
 
 func evalHashIndexExpression(hash, index object.Object) object.Object {
@@ -441,25 +1059,38 @@ func evalHashIndexExpression(hash, index object.Object) object.Object {
 	if !ok {
 		return newError("unusable as hash key: %s", index.Type())
 	}
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	value, ok := hashObject.Get(key)
 	if !ok {
 		return NULL
 	}
-	return pair.Value
+	return value
 }
 
 //adding eval for while node
+// a return or an error stops the loop and is passed on, break stops only this loop and continue goes straight to the condition
 func evalWhileExpression(we *ast.WhileStatement, env *object.Environment) object.Object {
-    condition := Eval(we.Condition, env)
-
-    if isError(condition) {
-        return condition
-    }
+	for {
+		condition := Eval(we.Condition, env)
+		if isControl(condition) {
+			return condition
+		}
+		if err := checkCondition(condition, we.Condition); err != nil {
+			return err
+		}
+		if !isTruthy(condition) {
+			return NULL
+		}
 
-    for isTruthy(condition) {
-        Eval(we.Body, env)
-        condition = Eval(we.Condition, env)
-    }
+		result := evalBlockStatement(we.Body, blockEnvironment(env))
+		if result == nil {
+			continue
+		}
 
-    return NULL
+		switch result.Type() {
+		case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
+			return result
+		case object.BREAK_OBJ:
+			return NULL
+		}
+	}
 }
\ No newline at end of file
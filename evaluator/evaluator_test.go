@@ -1,6 +1,7 @@
 package evaluator
 
 import (
+	"math"
 	"monkey/lexer"
 	"monkey/object"
 	"monkey/parser"
@@ -16,6 +17,12 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"10", 10},
 		{"5", 5},
 		{"10", 10},
+		{"0x1F", 31},
+		{"0o17", 15},
+		{"0b1010", 10},
+		{"1_000_000", 1000000},
+		{"5 // five", 5},
+		{"/* a /* b */ */ 5 * /* c */ 2", 10},
 		{"-5", -5},
 		{"-10", -10},
 		{"5", 5},
@@ -162,6 +169,19 @@ func testNullObject(t *testing.T, obj object.Object) bool {
 	return true
 }
 
+func testErrorObject(t *testing.T, obj object.Object, expected string) bool {
+	errObj, ok := obj.(*object.Error)
+	if !ok {
+		t.Errorf("no error object returned. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if errObj.Message != expected {
+		t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+		return false
+	}
+	return true
+}
+
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -331,6 +351,37 @@ func TestStringConcatenation(t *testing.T) {
 	}
 }
 
+func TestStringInterpolation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let sum = 3; "total: ${sum} items"`, "total: 3 items"},
+		{`"${1 + 2}${"a" + "b"}${true}"`, "3abtrue"},
+		{`let name = "x"; "${name}: ${[1, 2.5, "s"]}"`, "x: [1, 2.5, s]"},
+		{`"nested ${"in ${1 * 2}"}"`, "nested in 2"},
+		{`"cost: \$${5}"`, "cost: $5"},
+		{`"${y}"`, "identifier not found: y"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		if _, ok := evaluated.(*object.Error); ok {
+			testErrorObject(t, evaluated, tt.expected)
+			continue
+		}
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != tt.expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", tt.expected, str.Value)
+		}
+	}
+}
+
 func TestBuiltinFunctions(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -339,6 +390,10 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`len("")`, 0},
 		{`len("four")`, 4},
 		{`len("hello world")`, 11},
+		{`len("a\tb\"")`, 4},
+		{"len(`a\\tb`)", 4},
+		{`len("größe")`, 5},
+		{`len("😀")`, 1},
 		{`len(1)`, "argument to `len` not supported, got INTEGER"},
 		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
 	}
@@ -435,6 +490,166 @@ func TestArrayIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestStringIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"abc"[0]`, "a"},
+		{`"abc"[2]`, "c"},
+		{`"größe"[2]`, "ö"},
+		{`"größe"[4]`, "e"},
+		{`"a😀b"[1]`, "😀"},
+		{`let s = "héllo"; s[len(s) - 1]`, "o"},
+		{`"abc"[3]`, nil},
+		{`"größe"[5]`, nil},
+		{`"abc"[-1]`, nil},
+		{`""[0]`, nil},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		expected, ok := tt.expected.(string)
+		if !ok {
+			testNullObject(t, evaluated)
+			continue
+		}
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if str.Value != expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", expected, str.Value)
+		}
+	}
+}
+
+func TestNullHandling(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"null", nil},
+		{"null == null", true},
+		{"let x = if (false) { 1 }; x == null", true},
+		{"1 == null", false},
+		{"!null", true},
+		{"let f = fn() { return null; }; f()", nil},
+		{"null ?? 5", 5},
+		{"3 ?? 5", 3},
+		{"0 ?? 5", 0},
+		{"false ?? true", false},
+		{"null ?? null ?? 7", 7},
+		{"let n = 0; let a = 1 ?? (n = 2); n", 0},
+		{"null ?? missing", "identifier not found: missing"},
+		{`let h = {"a": {"b": 2}}; h?.a?.b`, 2},
+		{`let h = {"a": {"b": 2}}; h?["a"]?["b"]`, 2},
+		{`let h = null; h?.a`, nil},
+		{`let h = null; h?["a"]`, nil},
+		{`let h = {"a": null}; h?.a?.b`, nil},
+		{`let h = {}; h?.a?.b ?? "default"`, "default"},
+		{`let arr = null; arr?[0] ?? -1`, -1},
+		{`let arr = [1, 2]; arr?[1]`, 2},
+		{`let n = 0; let h = null; h?[n = 1]; n`, 0},
+		{`let h = 5; h?.a`, "field access not supported: INTEGER.a"},
+		{`let a = null; a?.b.c`, nil},
+		{`let a = null; a?.b["c"][0]`, nil},
+		{`let a = null; a?.b.c()`, nil},
+		{`let a = null; a?.f(1).g`, nil},
+		{`let a = null; a?["f"]()`, nil},
+		{`let n = 0; let a = null; a?.b[n = 1]; n`, 0},
+		{`let a = {"b": null}; a?.b.c`, "field access not supported: NULL.c"},
+		{`let a = null; a?.b.c ?? "default"`, "default"},
+		{`let a = null; [a?.b.c][0].d`, "field access not supported: NULL.d"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("String has wrong value. expected=%q, got=%q", expected, obj.Value)
+				}
+			case *object.Error:
+				testErrorObject(t, obj, expected)
+			default:
+				t.Errorf("object is not String or Error. got=%T (%+v)", evaluated, evaluated)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestDotAccessAndMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let cfg = {"server": {"port": 8080}}; cfg.server.port`, 8080},
+		{`let cfg = {"server": {"port": 8080}}; cfg.server.port == cfg["server"]["port"]`, true},
+		{`let cfg = {}; cfg.missing`, nil},
+		{`let cfg = {"server": {}}; cfg.server.port = 80; cfg.server.port`, 80},
+		{`let cfg = {"n": 1}; cfg.n += 1; cfg.n`, 2},
+		{`let obj = {"double": fn(x) { x * 2 }}; obj.double(21)`, 42},
+		{`let obj = {"len": fn() { 99 }}; obj.len()`, 99},
+		{"[1, 2, 3].len()", 3},
+		{"[1, 2, 3].push(4).last()", 4},
+		{"let arr = [1, 2]; arr.push(3).len() + arr.len()", 5},
+		{"[1, 2, 3].rest().first()", 2},
+		{`"hello".upper()`, "HELLO"},
+		{`"  MiXeD ".trim().lower()`, "mixed"},
+		{`"größe".len()`, 5},
+		{"2.7.floor()", 2},
+		{"let x = 3; x.float() / 2", 1.5},
+		{`let h = null; h?.upper()`, nil},
+		{`"a".nope()`, "unknown method: STRING.nope"},
+		{`let h = {}; h.nope()`, "unknown method: HASH.nope"},
+		{`[1].len`, "field access not supported: ARRAY.len"},
+		{`let obj = {"x": 1}; obj.x()`, "not a function: INTEGER"},
+		{`"a".upper(1)`, "wrong number of arguments to `upper`. got=1, want=0"},
+		{"[1].len(2)", "wrong number of arguments to `len`. got=1, want=0"},
+		{"[1].push()", "wrong number of arguments to `push`. got=0, want=1"},
+		{"2.5.floor(1)", "wrong number of arguments to `floor`. got=1, want=0"},
+		{`{"a": 1}.keys(1, 2)`, "wrong number of arguments to `keys`. got=2, want=0"},
+		{"len([1].push(2))", 2},
+		{`missing.len()`, "identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("String has wrong value. expected=%q, got=%q", expected, obj.Value)
+				}
+			case *object.Error:
+				testErrorObject(t, obj, expected)
+			default:
+				t.Errorf("object is not String or Error. got=%T (%+v)", evaluated, evaluated)
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
 func TestHashLiterals(t *testing.T) {
 	input := `let two = "two";
 		{
@@ -452,23 +667,152 @@ func TestHashLiterals(t *testing.T) {
 	if !ok {
 		t.Fatalf("Eval didn't return Hash. got=%T (%+v)", evaluated, evaluated)
 	}
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		TRUE.HashKey():                             5,
-		FALSE.HashKey():                            6,
+	expected := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{TRUE, 5},
+		{FALSE, 6},
 	}
-	if len(result.Pairs) != len(expected) {
-		t.Fatalf("Hash has wrong num of pairs. got=%d", len(result.Pairs))
+	if result.Len() != len(expected) {
+		t.Fatalf("Hash has wrong num of pairs. got=%d", result.Len())
 	}
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
+	for i, tt := range expected {
+		value, ok := result.Get(tt.key)
 		if !ok {
-			t.Errorf("no pair for given key in Pairs")
+			t.Errorf("no pair for given key %s", tt.key.Inspect())
+			continue
+		}
+		testIntegerObject(t, value, tt.value)
+
+		// pairs come back in the order of the literal
+		if key := result.Pairs()[i].Key; key.Inspect() != tt.key.Inspect() {
+			t.Errorf("pair %d has wrong key. expected=%s, got=%s", i, tt.key.Inspect(), key.Inspect())
+		}
+	}
+}
+
+func TestTuples(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"#(1, 2)", "#(1, 2)"},
+		{"#()", "#()"},
+		{`#(1, "a", #(true, 2n))`, "#(1, a, #(true, 2n))"},
+		{"#(1, 2)[1]", 2},
+		{"#(1, 2)[2]", nil},
+		{"len(#(1, 2, 3))", 3},
+		{"#(1, 2).len()", 2},
+		{"if (#()) { 1 } else { 2 }", 2},
+		{`let h = {#(1, 2): "x"}; h[#(1, 2)]`, "x"},
+		{`let h = {#(1, 2): "x"}; h[#(2, 1)]`, nil},
+		{`let h = {}; h[#(0, 0)] = "origin"; h[#(0, 0)] = "again"; len(keys(h))`, 1},
+		{`let h = {#(1, #("a")): 5}; h[#(1n, #("a"))]`, 5},
+		{`{#(1, 2): "x"}`, "{#(1, 2): x}"},
+		{"#(1, [2])", "unusable as tuple element: ARRAY"},
+		{"#(1, {})", "unusable as tuple element: HASH"},
+		{"let t = #(1); t[0] = 2", "index assignment not supported: TUPLE"},
+		{`{[1, 2]: "x"}`, "unusable as hash key: ARRAY"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if _, ok := evaluated.(*object.Error); ok {
+				testErrorObject(t, evaluated, expected)
+				continue
+			}
+			if evaluated.Inspect() != expected {
+				t.Errorf("%s: wrong result. expected=%s, got=%s", tt.input, expected, evaluated.Inspect())
+			}
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestStructuralEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"[1, 2] == [1, 2]", true},
+		{"[1, 2] != [1, 2]", false},
+		{"[1, 2] == [2, 1]", false},
+		{"[1, 2] == [1, 2, 3]", false},
+		{"[] == []", true},
+		{"[1, [2, [3]]] == [1, [2, [3]]]", true},
+		{"[1, [2, [3]]] == [1, [2, [4]]]", false},
+		{`[1, "a", true, null] == [1, "a", true, null]`, true},
+		{"[1] == [1.0]", true},
+		{"[1n] == [1]", true},
+		{`{"a": 1, "b": [2]} == {"b": [2], "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"b": 1}`, false},
+		{`{"a": 1} == {"a": 1, "b": 2}`, false},
+		{"{} == {}", true},
+		{"#(1, 2) == #(1, 2)", true},
+		{"#(1, 2) == [1, 2]", false},
+		{"let a = [1]; let b = a; a == b", true},
+		{"let a = [1]; let b = [1]; b[0] = 2; a == b", false},
+		{"[1] == 1", false},
+		{"let f = fn() { 1 }; [f] == [f]", true},
+		{"[fn() { 1 }] == [fn() { 1 }]", false},
+		{"let a = [1, 2]; a[1] = a; let b = [1, 2]; b[1] = b; a == b", true},
+		{"let a = [1, 2]; a[1] = a; let b = [5, 2]; b[1] = b; a == b", false},
+		{"let a = [1]; a[0] = a; let b = [1]; b[0] = a; a == b", true},
+		{`let h = {}; h["self"] = h; let g = {}; g["self"] = g; h == g`, true},
+		{`let h = {"n": 1}; h["self"] = h; let g = {"n": 2}; g["self"] = g; h != g`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestHashOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": 1, "a": 2, "c": 3}`, "{b: 1, a: 2, c: 3}"},
+		{`{3: "x", 1: "y", 2: "z"}`, "{3: x, 1: y, 2: z}"},
+		{`let h = {"z": 1}; h["a"] = 2; h["m"] = 3; h`, "{z: 1, a: 2, m: 3}"},
+		{`let h = {"z": 1, "a": 2}; h["z"] = 9; h`, "{z: 9, a: 2}"},
+		{`{"a": 1, "b": 2, "a": 3}`, "{a: 3, b: 2}"},
+		{`keys({"b": 1, "a": 2, "c": 3})`, "[b, a, c]"},
+		{`values({"b": 1, "a": 2, "c": 3})`, "[1, 2, 3]"},
+		{`entries({"b": 1, "a": [2]})`, "[[b, 1], [a, [2]]]"},
+		{`let h = {}; h.x = 1; h.y = 2; h.keys()`, "[x, y]"},
+		{`{"b": 1, "a": 2}.values()`, "[1, 2]"},
+		{`{}.entries()`, "[]"},
+		{`keys([1])`, "argument to `keys` must be HASH, got ARRAY"},
+		{`values({}, {})`, "wrong number of arguments. got=2, want=1"},
+	}
+
+	for _, tt := range tests {
+		// run each input several times, a Go map would come back in a different order sooner or later
+		for i := 0; i < 20; i++ {
+			evaluated := testEval(tt.input)
+			if _, ok := evaluated.(*object.Error); ok {
+				testErrorObject(t, evaluated, tt.expected)
+				break
+			}
+			if evaluated.Inspect() != tt.expected {
+				t.Errorf("%s: wrong result. expected=%s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+				break
+			}
 		}
-		testIntegerObject(t, pair.Value, expectedValue)
 	}
 }
 
@@ -523,7 +867,7 @@ func TestWhileLoop(t *testing.T) {
 		expected int64
 	}{
 		{
-			"let x = 0; while (x < 10) { let x = x + 1; } x",
+			"let x = 0; while (x < 10) { x = x + 1; } x",
 			10,
 		},
 		{
@@ -537,3 +881,636 @@ func TestWhileLoop(t *testing.T) {
 		testIntegerObject(t, evaluated, tt.expected)
 	}
 }
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedPos string
+	}{
+		{"5 + true;", "1:3"},
+		{"let x = 1;\nlet y = x + foo;", "2:13"},
+		{"let f = fn() {\n  -true\n};\nf();", "2:3"},
+		{"len(1, 2)", "1:1"},
+		{`{[1]: 2}`, "1:2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+		if errObj.Pos.String() != tt.expectedPos {
+			t.Errorf("wrong error position for %q. expected=%s, got=%s",
+				tt.input, tt.expectedPos, errObj.Pos)
+		}
+	}
+}
+
+func TestErrorStackTrace(t *testing.T) {
+	input := `let inner = fn(x) {
+  -x
+};
+let outer = fn() {
+  inner(true)
+};
+let apply = fn(f) { f() };
+apply(fn() { outer() });`
+
+	evaluated := testEval(input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	expected := []object.Frame{
+		{Function: "inner"},
+		{Function: "outer"},
+		{Function: "<anonymous>"},
+		{Function: "apply"},
+	}
+	expectedPos := []string{"5:3", "8:14", "7:21", "8:1"}
+
+	if len(errObj.Stack) != len(expected) {
+		t.Fatalf("wrong stack depth. expected=%d, got=%d (%+v)", len(expected), len(errObj.Stack), errObj.Stack)
+	}
+	for i, frame := range errObj.Stack {
+		if frame.Function != expected[i].Function {
+			t.Errorf("frame %d has wrong function. expected=%q, got=%q", i, expected[i].Function, frame.Function)
+		}
+		if frame.Pos.String() != expectedPos[i] {
+			t.Errorf("frame %d has wrong position. expected=%s, got=%s", i, expectedPos[i], frame.Pos)
+		}
+	}
+
+	traceback := `Traceback (most recent call last):
+  line 8, column 1, in <program>
+  line 7, column 21, in apply
+  line 8, column 14, in <anonymous>
+  line 5, column 3, in outer
+  line 2, column 3, in inner
+Error: unknown operator: -BOOLEAN
+`
+	if errObj.Traceback() != traceback {
+		t.Errorf("wrong traceback.\nexpected=%s\ngot=%s", traceback, errObj.Traceback())
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let x = 1; x = 2; x", 2},
+		{"let x = 1; x = x + 41", 42},
+		{"let x = 10; x += 5; x", 15},
+		{"let x = 10; x -= 5; x", 5},
+		{"let x = 10; x *= 5; x", 50},
+		{"let x = 10; x /= 5; x", 2},
+		{"let a = 0; let b = 0; a = b = 7; a + b", 14},
+		{"let x = 1; let f = fn() { x = 5; }; f(); x", 5},
+		{"let counter = fn() { let n = 0; fn() { n += 1 } }; let c = counter(); c(); c(); c()", 3},
+		{"let x = 1; let f = fn(x) { x = 5; }; f(2); x", 1},
+		{"let s = \"a\"; s += \"b\"; s", "ab"},
+		{"y = 1", "assignment to undeclared identifier: y"},
+		{"y += 1", "identifier not found: y"},
+		{"let x = 1; x += true", "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if str, ok := evaluated.(*object.String); ok {
+				if str.Value != expected {
+					t.Errorf("String has wrong value. got=%q, want=%q", str.Value, expected)
+				}
+				continue
+			}
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestIndexAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let a = [1, 2, 3]; a[0] = 10; a[0]", 10},
+		{"let a = [1, 2, 3]; a[1] = 5", 5},
+		{"let a = [1, 2, 3]; a[2] += 4; a[2]", 7},
+		{"let a = [1, 2, 3]; let b = a; b[0] = 9; a[0]", 9},
+		{"let a = [[1], [2]]; a[1][0] = 7; a[1][0]", 7},
+		{"let a = [0, 0]; let f = fn(arr) { arr[1] = 3; }; f(a); a[1]", 3},
+		{"let a = [1, 2]; let i = 0; while (i < 2) { a[i] *= 10; i += 1; } a[0] + a[1]", 30},
+		{`let h = {"a": 1}; h["a"] = 2; h["a"]`, 2},
+		{`let h = {}; h["new"] = 3; h["new"]`, 3},
+		{`let h = {}; h[1] = 1; h[true] = 2; h[1] + h[true]`, 3},
+		{`let h = {"n": 1}; h["n"] += 41; h["n"]`, 42},
+		{`let h = {"inner": {}}; h["inner"]["x"] = 5; h["inner"]["x"]`, 5},
+		{`let h = {}; h["s"] = "x"; h["s"] += "y"; h["s"]`, "xy"},
+		{"let a = [1, 2, 3]; a[3] = 4", "index out of range: 3 (array length 3)"},
+		{"let a = [1, 2, 3]; a[-1] = 4", "index out of range: -1 (array length 3)"},
+		{"let a = []; a[0] += 1", "index out of range: 0 (array length 0)"},
+		{`let a = [1]; a["x"] = 4`, "array index must be INTEGER, got STRING"},
+		{`let h = {}; h[[1]] = 1`, "unusable as hash key: ARRAY"},
+		{`let s = "abc"; s[0] = "x"`, "index assignment not supported: STRING"},
+		{`let h = {}; h["n"] += 1`, "type mismatch: NULL + INTEGER"},
+		{"a[0] = 1", "identifier not found: a"},
+		{"let a = [1]; a[0] = b", "identifier not found: b"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if str, ok := evaluated.(*object.String); ok {
+				if str.Value != expected {
+					t.Errorf("String has wrong value. got=%q, want=%q", str.Value, expected)
+				}
+				continue
+			}
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestInspectSelfContaining(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1]; a[0] = a; a", "[[...]]"},
+		{"let a = [1, 2]; a[1] = a; a", "[1, [...]]"},
+		{`let h = {"n": 1}; h["self"] = h; h`, "{n: 1, self: {...}}"},
+		{`let a = [1]; let h = {"a": a}; a[0] = h; a`, "[{a: [...]}]"},
+		{`let a = [1, 2]; a[1] = a; "${a}"`, "[1, [...]]"},
+		{"let b = [1]; let a = [b, b]; a", "[[1], [1]]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong Inspect for %q. expected=%q, got=%q", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestBlockScope(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let x = 1; if (true) { let x = 2; } x", 1},
+		{"let x = 1; if (true) { let x = 2; x } ", 2},
+		{"let x = 1; if (false) { 1 } else { let x = 3; } x", 1},
+		{"if (true) { let y = 2; } y", "identifier not found: y"},
+		{"let x = 1; if (true) { x = 2; } x", 2},
+		{"let i = 0; let s = 0; while (i < 3) { let t = i * 2; s += t; i += 1; } s", 6},
+		{"let i = 0; while (i < 3) { let t = i; i += 1; } t", "identifier not found: t"},
+		{"let f = fn() { if (true) { let z = 1; } z }; f()", "identifier not found: z"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestLeakyBlockScope(t *testing.T) {
+	LeakyBlockScope = true
+	defer func() { LeakyBlockScope = false }()
+
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let x = 1; if (true) { let x = 2; } x", 2},
+		{"if (true) { let y = 3; } y", 3},
+		{"let x = 0; while (x < 10) { let x = x + 1; } x", 10},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestWhileControlFlow(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let f = fn() { let i = 0; while (true) { if (i == 3) { return i * 10; } i += 1; } 99 }; f()", 30},
+		{"let i = 0; while (i < 10) { i += 1; -true; } i", "unknown operator: -BOOLEAN"},
+		{"let i = 0; while (i < 10) { if (i == 4) { break; } i += 1; } i", 4},
+		{"let i = 0; let s = 0; while (i < 5) { i += 1; if (i == 2) { continue; } s += i; } s", 13},
+		{`
+		let i = 0;
+		let count = 0;
+		while (i < 3) {
+			let j = 0;
+			while (true) {
+				if (j == 2) { break; }
+				j += 1;
+				count += 1;
+			}
+			i += 1;
+			if (i == 1) { continue; }
+			count += 100;
+		}
+		count`, 206},
+		{"let i = 0; while (i < 3) { i += 1; } ", nil},
+		{"let i = 0; while (i < 3) { i += 1; let x = if (true) { break; }; } i", 1},
+		{"let i = 0; let n = 0; while (i < 3) { i += 1; puts(if (true) { continue; }); n += 1; } n", 0},
+		{"let i = 0; let n = 0; while (i < 3) { i += 1; let a = [1, if (true) { continue; }]; n += 1; } n", 0},
+		{`let i = 0; while (i < 3) { i += 1; let h = {"k": if (true) { break; }}; } i`, 1},
+		{"let i = 0; while (i < 3) { i += 1; let t = #(1, if (true) { break; }); } i", 1},
+		{"let i = 0; while (i < 3) { i += 1; let x = 1 + if (true) { break; }; } i", 1},
+		{"let f = fn() { let x = if (true) { return 5; }; 10 }; f()", 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestTruthiness(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"!0", true},
+		{"!1", false},
+		{"!-1", false},
+		{`!""`, true},
+		{`!"a"`, false},
+		{"![]", true},
+		{"![0]", false},
+		{"!{}", true},
+		{`!{"a": 1}`, false},
+		{"!fn() {}", false},
+		{"!len", false},
+		{"!if (false) { 1 }", true},
+		{"if (0) { true } else { false }", false},
+		{`if ("x") { true } else { false }`, true},
+		{"if ([]) { true } else { false }", false},
+		{"let n = 3; let c = 0; while (n) { n -= 1; c += 1; } c == 3", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestStrictTruthiness(t *testing.T) {
+	StrictTruthiness = true
+	defer func() { StrictTruthiness = false }()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"if (1) { 10 }", "non-boolean condition: INTEGER"},
+		{"while (if (false) { 1 }) { 1 }", "non-boolean condition: NULL"},
+		{`!"a"`, "unknown operator: !STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testErrorObject(t, evaluated, tt.expected)
+	}
+
+	testIntegerObject(t, testEval("if (1 < 2) { 10 } else { 20 }"), 10)
+	testBooleanObject(t, testEval("!false"), true)
+}
+
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"0 || 5", 5},
+		{"3 && 5", 5},
+		{"0 && 5", 0},
+		{`"" || "default"`, "default"},
+		{"false && undefinedName", false},
+		{"true || undefinedName", true},
+		{"let calls = 0; let f = fn() { calls += 1; true }; false && f(); true || f(); calls", 0},
+		{"true && undefinedName", "identifier not found: undefinedName"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.String:
+				if obj.Value != expected {
+					t.Errorf("String has wrong value. got=%q, want=%q", obj.Value, expected)
+				}
+			case *object.Error:
+				testErrorObject(t, obj, expected)
+			default:
+				t.Errorf("unexpected object for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			}
+		}
+	}
+
+	StrictTruthiness = true
+	defer func() { StrictTruthiness = false }()
+
+	testErrorObject(t, testEval("true && 1"), "non-boolean condition: INTEGER")
+}
+
+func TestExtendedOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{`"a" <= "b"`, true},
+		{`"b" >= "c"`, false},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"2 ** 10", 1024},
+		{"2 ** 3 ** 2", 512},
+		{"-2 ** 2", -4},
+		{"5 ** 0", 1},
+		{"6 & 3", 2},
+		{"6 | 3", 7},
+		{"6 ^ 3", 5},
+		{"~5", -6},
+		{"1 << 4", 16},
+		{"-16 >> 2", -4},
+		{"1 << -1", "negative shift count: 1 << -1"},
+		{"~true", "unknown operator: ~BOOLEAN"},
+		{"true & false", "unknown operator: BOOLEAN & BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 / 0", "division by zero: 1 / 0"},
+		{"let x = 0; 10 % x", "modulo by zero: 10 % 0"},
+		{"let x = 5; x /= 0", "division by zero: 5 / 0"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testErrorObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestCheckedArithmetic(t *testing.T) {
+	wrapping := []struct {
+		input    string
+		expected int64
+	}{
+		{"9223372036854775807 + 1", math.MinInt64},
+		{"2 ** 64", 0},
+	}
+	for _, tt := range wrapping {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+
+	CheckedArithmetic = true
+	defer func() { CheckedArithmetic = false }()
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"9223372036854775807 + 1", "integer overflow: 9223372036854775807 + 1"},
+		{"-9223372036854775807 - 2", "integer overflow: -9223372036854775807 - 2"},
+		{"4611686018427387904 * 2", "integer overflow: 4611686018427387904 * 2"},
+		{"-1 * (-9223372036854775807 - 1)", "integer overflow: -1 * -9223372036854775808"},
+		{"(-9223372036854775807 - 1) / -1", "integer overflow: -9223372036854775808 / -1"},
+		{"-(-9223372036854775807 - 1)", "integer overflow: -(-9223372036854775808)"},
+		{"2 ** 63", "integer overflow: 2 ** 63"},
+		{"9223372036854775807 - 1", 9223372036854775806},
+		{"-4611686018427387904 * 2", math.MinInt64},
+		{"2 ** 62", 4611686018427387904},
+		{"(-2) ** 63", math.MinInt64},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestFloatExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"3.5", 3.5},
+		{"-2.5", -2.5},
+		{"1.5 + 1.5", 3.0},
+		{"1 + 0.5", 1.5},
+		{"0.5 * 4", 2.0},
+		{"7 / 2.0", 3.5},
+		{"7.5 % 2", 1.5},
+		{"2 ** 0.5 ** 2", 1.189207115002721},
+		{"2 ** -1", 0.5},
+		{"let x = 1; x += 0.25; x", 1.25},
+		{"1 == 1.0", true},
+		{"1.5 > 1", true},
+		{"2 <= 1.5", false},
+		{"0.1 + 0.2 != 0.3", true},
+		{"if (0.0) { true } else { false }", false},
+		{"1.0 / 0", "division by zero: 1.0 / 0"},
+		{"1.5 & 1", "unknown operator: FLOAT & INTEGER"},
+		{`1.5 + "a"`, "type mismatch: FLOAT + STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is not Float. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%g, want=%g", result.Value, expected)
+		return false
+	}
+	return true
+}
+
+func TestNumericBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"int(3.9)", 3},
+		{"int(-3.9)", -3},
+		{"int(7)", 7},
+		{`int("42")`, 42},
+		{`int("0x1F")`, 31},
+		{"int(true)", 1},
+		{"float(2)", 2.0},
+		{`float("2.5")`, 2.5},
+		{"floor(2.7)", 2},
+		{"floor(-2.1)", -3},
+		{"ceil(2.1)", 3},
+		{"round(2.5)", 3},
+		{"round(-2.5)", -3},
+		{"round(4)", 4},
+		{"sqrt(16)", 4.0},
+		{"sqrt(2.25)", 1.5},
+		{`int("abc")`, `could not convert "abc" to INTEGER`},
+		{"int(1e300)", "argument to `int` out of INTEGER range, got 1e+300"},
+		{"sqrt(-1)", "argument to `sqrt` must not be negative, got -1"},
+		{`floor("1")`, "argument to `floor` must be INTEGER or FLOAT, got STRING"},
+		{"float([])", "argument to `float` not supported, got ARRAY"},
+		{"ceil(1, 2)", "wrong number of arguments. got=2, want=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case string:
+			testErrorObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestBigIntExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"123n", "123n"},
+		{"0x10n", "16n"},
+		{"0xFFFF_FFFF_FFFF_FFFF_FFn", "4722366482869645213695n"},
+		{"9223372036854775807n + 1", "9223372036854775808n"},
+		{"2n ** 100", "1267650600228229401496703205376n"},
+		{"-(2n ** 64) - 1", "-18446744073709551617n"},
+		{"10n / 3", "3n"},
+		{"-7n % 3", "-1n"},
+		{"6n & 3n", "2n"},
+		{"6n | 3", "7n"},
+		{"6n ^ 3", "5n"},
+		{"~5n", "-6n"},
+		{"1n << 70", "1180591620717411303424n"},
+		{"(1n << 70) >> 68", "4n"},
+		{"let f = fn(n) { if (n < 2) { return n; } let a = 0n; let b = 1n; let i = 1; while (i < n) { let t = a + b; a = b; b = t; i += 1; } b }; f(100)", "354224848179261915075n"},
+		{"5n == 5", true},
+		{"5n != 5n", false},
+		{"2n ** 64 > 9223372036854775807", true},
+		{"1n <= 0", false},
+		{"if (0n) { true } else { false }", false},
+		{"1n + 0.5", 1.5},
+		{"2n ** -1", 0.5},
+		{`{1: "one"}[1n]`, "one"},
+		{`{2n ** 64: "big"}[2n ** 64]`, "big"},
+		{"int(42n)", 42},
+		{"int(2n ** 64)", "argument to `int` out of INTEGER range, got 18446744073709551616n"},
+		{"1n / 0", "division by zero: 1n / 0"},
+		{"1n << -1", "negative shift count: 1n << -1"},
+		{"(2n ** 1048575) >> 1048574", "2n"},
+		{"2n ** 1048576", "big integer too large: result of ** has more than 1048576 bits"},
+		{"2n ** 100000000000000000000n", "big integer too large: result of ** has more than 1048576 bits"},
+		{"3n ** 1000000", "big integer too large: result of ** has more than 1048576 bits"},
+		{"1n ** 100000000000000000000n", "1n"},
+		{"(-1n) ** 100000000001", "-1n"},
+		{"1n << 2147483647", "big integer too large: result of << has more than 1048576 bits"},
+		{"0n << 2147483647", "0n"},
+		{"let x = 2n ** 1000000; x * x", "big integer too large: result of * has more than 1048576 bits"},
+		{`1n + "a"`, "type mismatch: BIGINT + STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case float64:
+			testFloatObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			switch obj := evaluated.(type) {
+			case *object.Error:
+				testErrorObject(t, obj, expected)
+			case *object.BigInt, *object.String:
+				if obj.Inspect() != expected {
+					t.Errorf("wrong value for %q. expected=%s, got=%s", tt.input, expected, obj.Inspect())
+				}
+			default:
+				t.Errorf("unexpected object for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			}
+		}
+	}
+}
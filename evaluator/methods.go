@@ -0,0 +1,71 @@
+package evaluator
+
+import (
+	"monkey/object"
+	"strings"
+)
+
+// methods callable as value.name(args), by the type of the value. the value is passed as the first
+// argument, so arr.push(4) is the same as push(arr, 4)
+var methods = map[object.ObjectType]map[string]*object.Builtin{
+	object.ARRAY_OBJ: {
+		"len":   builtinMethod("len", 0),
+		"first": builtinMethod("first", 0),
+		"last":  builtinMethod("last", 0),
+		"rest":  builtinMethod("rest", 0),
+		"push":  builtinMethod("push", 1),
+	},
+	object.STRING_OBJ: {
+		"len":   builtinMethod("len", 0),
+		"upper": stringMethod("upper", strings.ToUpper),
+		"lower": stringMethod("lower", strings.ToLower),
+		"trim":  stringMethod("trim", strings.TrimSpace),
+	},
+	object.TUPLE_OBJ: {
+		"len": builtinMethod("len", 0),
+	},
+	object.HASH_OBJ: {
+		"keys":    builtinMethod("keys", 0),
+		"values":  builtinMethod("values", 0),
+		"entries": builtinMethod("entries", 0),
+	},
+	object.INTEGER_OBJ: {
+		"float": builtinMethod("float", 0),
+	},
+	object.FLOAT_OBJ: {
+		"int":   builtinMethod("int", 0),
+		"floor": builtinMethod("floor", 0),
+		"ceil":  builtinMethod("ceil", 0),
+		"round": builtinMethod("round", 0),
+	},
+}
+
+// a method that calls the builtin called name with the value in front of the arguments. the number of
+// arguments is checked here so the error counts only what was written between the parentheses
+func builtinMethod(name string, params int) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args)-1 != params {
+				return newError("wrong number of arguments to `%s`. got=%d, want=%d", name, len(args)-1, params)
+			}
+			return builtins[name].Fn(args...)
+		},
+	}
+}
+
+// a method taking no arguments that turns a string into another one with fn
+func stringMethod(name string, fn func(string) string) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments to `%s`. got=%d, want=0", name, len(args)-1)
+			}
+
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return newError("`%s` must be called on STRING, got %s", name, args[0].Type())
+			}
+			return &object.String{Value: fn(str.Value)}
+		},
+	}
+}
@@ -1,41 +1,101 @@
 package lexer
 
-import "monkey/token"
+import (
+	"fmt"
+	"monkey/diagnostic"
+	"monkey/token"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
 
 type Lexer struct {
+	file         string
 	input        string
 	position     int
 	readPosition int
-	ch           byte
+	ch           rune // current character, utf8.RuneError for a byte that isn't valid UTF-8
+
+	line   int // line of l.ch
+	column int // column of l.ch, counted in characters rather than bytes
+
+	diagnostics []diagnostic.Diagnostic // problems found so far, every ILLEGAL token has one
+
+	// one entry per ${ of a string that is still open, counting the { opened inside it,
+	// a } closing the ${ continues the string instead of being an RBRACE
+	templates []int
+
+	keepComments bool // attach comments to the token after them instead of dropping them
 }
 
 //initiates the Lexer puts the position at 0th pos and readPos at 1st pos
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
+	return NewWithFile("", input)
+}
+
+// same as New but every token position also records the file name
+func NewWithFile(file string, input string) *Lexer {
+	l := &Lexer{file: file, input: input, line: 1}
 	// readPos = 0 &&  position = 0 
 	l.readChar()
 	// now readPos = 1 && position = 1
 	return l
 }
 
-// returns the byte at readPos's postion in l.input
-func (l *Lexer) peekChar() byte {
+// makes the lexer keep comments, each token then carries the comments found right before it in
+// its Comments field, comments at the end of the input go on the EOF token
+func (l *Lexer) SetKeepComments(keep bool) {
+	l.keepComments = keep
+}
+
+// returns the problems found in the tokens read so far
+func (l *Lexer) Diagnostics() []diagnostic.Diagnostic {
+	return l.diagnostics
+}
+
+// records an error covering the given span of source
+func (l *Lexer) addError(code diagnostic.Code, span token.Span, msg string, hints ...string) {
+	l.diagnostics = append(l.diagnostics, diagnostic.Diagnostic{
+		Severity: diagnostic.Error,
+		Code:     code,
+		Span:     span,
+		Message:  msg,
+		Hints:    hints,
+	})
+}
+
+// returns the character at readPos's postion in l.input
+func (l *Lexer) peekChar() rune {
 	if l.readPosition >= len(l.input) {
 		return 0
 	} else {
-		return l.input[l.readPosition]
+		r, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+		return r
 	}
 }
 
 // increments both l.readPos and l.position
 func (l *Lexer) readChar() {
+	// already sitting on the end of the input, keep the position where it is
+	if l.readPosition > len(l.input) {
+		return
+	}
+
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.column++
+
+	width := 1
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
 	} else {
-		l.ch = l.input[l.readPosition]
+		l.ch, width = utf8.DecodeRuneInString(l.input[l.readPosition:])
 	}
 	l.position = l.readPosition
-	l.readPosition += 1
+	l.readPosition += width
 }
 
 // skips whitespaces
@@ -45,11 +105,99 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
-// function to identify the current charater/(string of characters) and assign this entity with the appropriate token 
+// skips whitespaces and comments, returns the comments when the lexer keeps them
+func (l *Lexer) skipTrivia() []token.Comment {
+	var comments []token.Comment
+
+	for {
+		l.skipWhitespace()
+		if l.ch != '/' || (l.peekChar() != '/' && l.peekChar() != '*') {
+			return comments
+		}
+
+		start := l.pos()
+		if l.peekChar() == '/' {
+			l.skipLineComment()
+		} else {
+			l.skipBlockComment()
+		}
+
+		if l.keepComments {
+			comments = append(comments, token.Comment{
+				Text: l.input[start.Offset:l.position],
+				Span: token.Span{Start: start, End: l.pos()},
+			})
+		}
+	}
+}
+
+// skips a // comment up to the end of the line, the newline itself is left alone
+func (l *Lexer) skipLineComment() {
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+}
+
+// skips a /* */ comment, a /* inside it opens a nested comment that needs its own */
+func (l *Lexer) skipBlockComment() {
+	start := l.pos()
+	l.readChar()
+	l.readChar()
+
+	depth := 1
+	for depth > 0 {
+		switch {
+		case l.ch == 0:
+			l.addError(diagnostic.UnterminatedComment, token.Span{Start: start, End: l.pos()}, "unterminated comment",
+				"add a closing */, every /* inside the comment needs one too")
+			return
+		case l.ch == '/' && l.peekChar() == '*':
+			depth++
+			l.readChar()
+		case l.ch == '*' && l.peekChar() == '/':
+			depth--
+			l.readChar()
+		}
+		l.readChar()
+	}
+}
+
+// returns the position of l.ch
+func (l *Lexer) pos() token.Position {
+	return token.Position{File: l.file, Line: l.line, Column: l.column, Offset: l.position}
+}
+
+// returns the position right after l.ch, l.ch must not be a newline
+func (l *Lexer) posAfter() token.Position {
+	return token.Position{File: l.file, Line: l.line, Column: l.column + 1, Offset: l.readPosition}
+}
+
+// returns the next token along with the span of source it covers
 func (l *Lexer) NextToken() token.Token {
-	var tok token.Token
+	comments := l.skipTrivia()
 
-	l.skipWhitespace()
+	start := l.pos()
+	reported := len(l.diagnostics)
+	tok := l.scanToken()
+	tok.Span = token.Span{Start: start, End: l.pos()}
+	tok.Comments = comments
+
+	// malformed numbers are already reported by readNumber, which knows what is wrong with them
+	if tok.Type == token.ILLEGAL && len(l.diagnostics) == reported {
+		if utf8.ValidString(tok.Literal) {
+			l.addError(diagnostic.IllegalCharacter, tok.Span, fmt.Sprintf("illegal character %q", tok.Literal))
+		} else {
+			l.addError(diagnostic.IllegalCharacter, tok.Span, fmt.Sprintf("invalid UTF-8 encoding %q", tok.Literal),
+				"source files have to be encoded in UTF-8")
+		}
+	}
+
+	return tok
+}
+
+// function to identify the current charater/(string of characters) and assign this entity with the appropriate token 
+func (l *Lexer) scanToken() token.Token {
+	var tok token.Token
 
 	switch l.ch {
 	case '=':
@@ -69,13 +217,23 @@ func (l *Lexer) NextToken() token.Token {
 	case ',':
 		tok = newToken(token.COMMA, l.ch)
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		tok = l.newTokenOr('=', token.PLUS_ASSIGN, token.PLUS)
 	case '{':
+		if n := len(l.templates); n > 0 {
+			l.templates[n-1]++
+		}
 		tok = newToken(token.LBRACE, l.ch)
 	case '}':
+		if n := len(l.templates); n > 0 {
+			if l.templates[n-1] == 0 {
+				l.templates = l.templates[:n-1]
+				return l.readString(true)
+			}
+			l.templates[n-1]--
+		}
 		tok = newToken(token.RBRACE, l.ch)
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		tok = l.newTokenOr('=', token.MINUS_ASSIGN, token.MINUS)
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -85,22 +243,61 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.BANG, l.ch)
 		}
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch)
+		if l.peekChar() == '*' {
+			tok = l.newTokenOr('*', token.POWER, token.ASTERISK)
+		} else {
+			tok = l.newTokenOr('=', token.ASTERISK_ASSIGN, token.ASTERISK)
+		}
 	case '/':
-		tok = newToken(token.SLASH, l.ch)
+		tok = l.newTokenOr('=', token.SLASH_ASSIGN, token.SLASH)
+	case '%':
+		tok = newToken(token.PERCENT, l.ch)
+	case '&':
+		tok = l.newTokenOr('&', token.AND, token.AMPERSAND)
+	case '|':
+		tok = l.newTokenOr('|', token.OR, token.PIPE)
+	case '^':
+		tok = newToken(token.CARET, l.ch)
+	case '~':
+		tok = newToken(token.TILDE, l.ch)
+	case '#':
+		tok = l.newTokenOr('(', token.TUPLE_LPAREN, token.ILLEGAL)
+	case '?':
+		// only ??, ?. and ?[ exist, a lone ? is illegal
+		tok = newToken(token.ILLEGAL, l.ch)
+		if tokenType, ok := questionTokens[l.peekChar()]; ok {
+			l.readChar()
+			tok = token.Token{Type: tokenType, Literal: "?" + string(l.ch)}
+		}
 	case '<':
-		tok = newToken(token.LT, l.ch)
+		if l.peekChar() == '<' {
+			tok = l.newTokenOr('<', token.SHIFT_LEFT, token.LT)
+		} else {
+			tok = l.newTokenOr('=', token.LT_EQ, token.LT)
+		}
 	case '>':
-		tok = newToken(token.GT, l.ch)
+		if l.peekChar() == '>' {
+			tok = l.newTokenOr('>', token.SHIFT_RIGHT, token.GT)
+		} else {
+			tok = l.newTokenOr('=', token.GT_EQ, token.GT)
+		}
 	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		return l.readString(false)
+	case '`':
+		return l.readRawString()
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch)
 	case ']':
 		tok = newToken(token.RBRACKET, l.ch)
 	case ':':
 		tok = newToken(token.COLON, l.ch)
+	case '.':
+		// a dot followed by a digit is a number like .5
+		if isDigit(l.peekChar()) {
+			tok.Type, tok.Literal = l.readNumber()
+			return tok
+		}
+		tok = newToken(token.DOT, l.ch)
 	case 0: // for end of line/file
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -110,29 +307,160 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Type = token.GetIdentfierType(tok.Literal)
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+			tok.Type, tok.Literal = l.readNumber()
 			return tok
 		} else {
-			tok = newToken(token.ILLEGAL, l.ch)
+			// taken from the input rather than l.ch so an invalid UTF-8 byte shows up as itself
+			tok = token.Token{Type: token.ILLEGAL, Literal: l.input[l.position:l.readPosition]}
 		}
 	}
 	l.readChar()
 	return tok
 }
 
-// returns the number
-func (l *Lexer) readNumber() string {
+// returns the number: an INT like 42, 0x1F, 0o17, 0b1010 or 1_000, a FLOAT like 3.14, .5 or 1e-9
+// or a BIGINT like 12n. a malformed number like 0x or 12abc is read as a whole and comes back as ILLEGAL
+func (l *Lexer) readNumber() (token.TokenType, string) {
+	start := l.pos()
 	position := l.position
-	for isDigit(l.ch) {
+	tokenType := token.TokenType(token.INT)
+	base := "decimal"
+	problem := ""
+
+	if l.ch == '0' && baseName(l.peekChar()) != "" {
+		base = baseName(l.peekChar())
+		l.readChar()
+		l.readChar()
+		// an underscore may follow the prefix directly, like 0x_FF
+		count, ok := l.readDigits(digitCheck(base), true)
+		// 0o8 has a digit, just not one of this base, that is reported below like the 2 in 0b102
+		if count == 0 && !isLetter(l.ch) && !isDigit(l.ch) {
+			problem = base + " literal has no digits"
+		} else if count > 0 && !ok {
+			problem = "'_' must separate successive digits"
+		}
+	} else {
+		_, ok := l.readDigits(isDigit, false)
+
+		// fraction, a dot has to be followed by a digit to belong to the number
+		if l.ch == '.' && isDigit(l.peekChar()) {
+			tokenType = token.FLOAT
+			l.readChar()
+			if _, fracOk := l.readDigits(isDigit, false); !fracOk {
+				ok = false
+			}
+		}
+
+		// exponent, e or E followed by digits with an optional sign
+		if l.ch == 'e' || l.ch == 'E' {
+			tokenType = token.FLOAT
+			l.readChar()
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+			count, expOk := l.readDigits(isDigit, false)
+			if count == 0 {
+				problem = "exponent has no digits"
+			} else if !expOk {
+				ok = false
+			}
+		}
+
+		if problem == "" && !ok {
+			problem = "'_' must separate successive digits"
+		}
+	}
+
+	// n suffix on an integer makes it a big integer
+	if tokenType == token.INT && l.ch == 'n' {
+		tokenType = token.BIGINT
 		l.readChar()
 	}
 
-	return l.input[position:l.position]
+	// letters or digits glued to the end make the whole word malformed, 12abc is not 12 followed by abc
+	if problem == "" && (isLetter(l.ch) || isDigit(l.ch)) {
+		what := "character"
+		if isDigit(l.ch) {
+			what = "digit"
+		}
+		problem = fmt.Sprintf("invalid %s %q in %s literal", what, l.ch, base)
+	}
+	for isLetter(l.ch) || isDigit(l.ch) {
+		l.readChar()
+	}
+
+	literal := l.input[position:l.position]
+
+	// ParseInt would silently read 017 as octal, so leading zeros are not allowed at all
+	if problem == "" && tokenType != token.FLOAT && base == "decimal" && len(literal) > 1 && literal[0] == '0' && literal[1] != 'n' {
+		l.addError(diagnostic.MalformedNumber, token.Span{Start: start, End: l.pos()}, "leading zeros are not allowed in decimal literals",
+			"use the 0o prefix for an octal number, like 0o17")
+		return token.ILLEGAL, literal
+	}
+
+	if problem != "" {
+		l.addError(diagnostic.MalformedNumber, token.Span{Start: start, End: l.pos()}, problem)
+		return token.ILLEGAL, literal
+	}
+	return tokenType, literal
+}
+
+// reads a run of digits accepted by valid, with single underscores between them.
+// returns how many digits were read and whether every underscore separated two digits,
+// afterPrefix allows an underscore right at the start
+func (l *Lexer) readDigits(valid func(rune) bool, afterPrefix bool) (int, bool) {
+	count := 0
+	ok := true
+	prevDigit := afterPrefix
+
+	for valid(l.ch) || l.ch == '_' {
+		if l.ch == '_' {
+			if !prevDigit || !valid(l.peekChar()) {
+				ok = false
+			}
+			prevDigit = false
+		} else {
+			count++
+			prevDigit = true
+		}
+		l.readChar()
+	}
+	return count, ok
+}
+
+// returns the name of the base a 0x, 0o or 0b prefix stands for, "" for anything else
+func baseName(ch rune) string {
+	switch ch {
+	case 'x', 'X':
+		return "hexadecimal"
+	case 'o', 'O':
+		return "octal"
+	case 'b', 'B':
+		return "binary"
+	}
+	return ""
+}
+
+// returns the function checking the digits of a base named by baseName
+func digitCheck(base string) func(rune) bool {
+	switch base {
+	case "hexadecimal":
+		return isHexDigit
+	case "octal":
+		return func(ch rune) bool { return '0' <= ch && ch <= '7' }
+	case "binary":
+		return func(ch rune) bool { return ch == '0' || ch == '1' }
+	}
+	return isDigit
+}
+
+//returns whether the current char is a hexadecimal digit
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
 }
 
 //returns bool value to check whether the current char is a bool
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 } 
 
@@ -146,25 +474,155 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[position:l.position]
 }
 
-//returns checking if the ch is a character or not
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+//returns checking if the ch is a character or not, any unicode letter counts
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' || ch > utf8.RuneSelf && unicode.IsLetter(ch)
 } 
 
 // return a new token according to a parameters
-func newToken(tokenType token.TokenType, ch byte) token.Token {
+func newToken(tokenType token.TokenType, ch rune) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch)}
 }
 
-// function to read string
-func (l *Lexer) readString() string {
+// tokens starting with ?, by their second character
+var questionTokens = map[rune]token.TokenType{
+	'?': token.NULLISH,
+	'.': token.OPTIONAL_DOT,
+	'[': token.OPTIONAL_LBRACKET,
+}
+
+// returns the two character token when the next character is second (like += or <<), the one character token otherwise
+func (l *Lexer) newTokenOr(second rune, two token.TokenType, one token.TokenType) token.Token {
+	if l.peekChar() == second {
+		ch := l.ch
+		l.readChar()
+		return token.Token{Type: two, Literal: string(ch) + string(l.ch)}
+	}
+	return newToken(one, l.ch)
+}
+
+// function to read a "..." string, escape sequences like \n or \u{1F600} are replaced by what they stand for.
+// a ${ stops the string with a TEMPLATE_HEAD, the } closing it calls readString again with continued set
+// to read the rest as a TEMPLATE_MIDDLE or TEMPLATE_TAIL.
+// a string missing its closing quote on the same line comes back as ILLEGAL
+func (l *Lexer) readString(continued bool) token.Token {
+	start := l.pos()
+	position := l.position
+	var out strings.Builder
+
+	for {
+		l.readChar()
+		switch l.ch {
+		case '"':
+			l.readChar()
+			if continued {
+				return token.Token{Type: token.TEMPLATE_TAIL, Literal: out.String()}
+			}
+			return token.Token{Type: token.STRING, Literal: out.String()}
+		case '$':
+			if l.peekChar() != '{' {
+				out.WriteRune(l.ch)
+				continue
+			}
+			l.readChar()
+			l.readChar()
+			l.templates = append(l.templates, 0)
+			if continued {
+				return token.Token{Type: token.TEMPLATE_MIDDLE, Literal: out.String()}
+			}
+			return token.Token{Type: token.TEMPLATE_HEAD, Literal: out.String()}
+		case 0, '\n':
+			l.addError(diagnostic.UnterminatedString, token.Span{Start: start, End: l.pos()}, "unterminated string",
+				`add a closing " to the end of the string`,
+				"use a `backtick` string for text that spans several lines")
+			return token.Token{Type: token.ILLEGAL, Literal: l.input[position:l.position]}
+		case '\\':
+			// a backslash right before the end of the line is left for the unterminated string error
+			if next := l.peekChar(); next == 0 || next == '\n' {
+				continue
+			}
+			l.readEscape(&out)
+		default:
+			// copied from the input so a byte that isn't valid UTF-8 stays as it is
+			out.WriteString(l.input[l.position:l.readPosition])
+		}
+	}
+}
+
+// reads the escape sequence starting at the backslash in l.ch and writes what it stands for to out,
+// l.ch is left on the last character of the sequence
+func (l *Lexer) readEscape(out *strings.Builder) {
+	start := l.pos()
+	l.readChar()
+
+	switch l.ch {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case 'r':
+		out.WriteByte('\r')
+	case '"':
+		out.WriteByte('"')
+	case '$':
+		out.WriteByte('$')
+	case '\\':
+		out.WriteByte('\\')
+	case 'u':
+		l.readUnicodeEscape(start, out)
+	default:
+		l.addError(diagnostic.InvalidEscape, token.Span{Start: start, End: l.posAfter()},
+			fmt.Sprintf("unknown escape sequence \\%c", l.ch),
+			`the escape sequences are \n \t \r \" \$ \\ and \u{...}, use \\ for a backslash`)
+	}
+}
+
+// reads the {1F600} part of a \u{1F600} escape, l.ch starts on the u
+func (l *Lexer) readUnicodeEscape(start token.Position, out *strings.Builder) {
+	if l.peekChar() != '{' {
+		l.addError(diagnostic.InvalidEscape, token.Span{Start: start, End: l.posAfter()},
+			"invalid unicode escape, expected \\u{...}", "write the code point in hex between braces, like \\u{1F600}")
+		return
+	}
+	l.readChar()
+
+	digits := l.position + 1
+	for isHexDigit(l.peekChar()) {
+		l.readChar()
+	}
+	hex := l.input[digits : l.position+1]
+	if l.peekChar() != '}' || len(hex) == 0 || len(hex) > 6 {
+		l.addError(diagnostic.InvalidEscape, token.Span{Start: start, End: l.posAfter()},
+			"invalid unicode escape, expected 1 to 6 hex digits between braces", "write the code point in hex between braces, like \\u{1F600}")
+		return
+	}
+	l.readChar()
+
+	code, _ := strconv.ParseUint(hex, 16, 32)
+	if code > 0x10FFFF || (0xD800 <= code && code <= 0xDFFF) {
+		l.addError(diagnostic.InvalidEscape, token.Span{Start: start, End: l.posAfter()},
+			fmt.Sprintf("\\u{%s} is not a valid unicode code point", hex))
+		return
+	}
+	out.WriteRune(rune(code))
+}
+
+// function to read a `...` raw string, it has no escape sequences and may span several lines
+func (l *Lexer) readRawString() token.Token {
+	start := l.pos()
 	position := l.position + 1
 
 	for {
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
-			break
+		switch l.ch {
+		case '`':
+			literal := l.input[position:l.position]
+			l.readChar()
+			return token.Token{Type: token.STRING, Literal: literal}
+		case 0:
+			l.addError(diagnostic.UnterminatedString, token.Span{Start: start, End: l.pos()}, "unterminated raw string",
+				"add a closing ` to the end of the string")
+			return token.Token{Type: token.ILLEGAL, Literal: l.input[position-1 : l.position]}
 		}
 	}
-	return l.input[position:l.position]
-}
\ No newline at end of file
+}
@@ -1,6 +1,7 @@
 package lexer
 
 import (
+	"monkey/diagnostic"
 	"monkey/token"
 	"testing"
 )
@@ -13,7 +14,7 @@ func TestNextToken(t *testing.T) {
 		x + y;
 		};
 		let result = add(five, ten);
-		!-/*5;
+		!-/ *5;
 		5 < 10 > 5;
 		if (5 < 10) {
 		return true;
@@ -197,3 +198,616 @@ func TestWhileLoop(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 5;\n  x + \"ab\""
+
+	tests := []struct {
+		expectedType token.TokenType
+		startLine    int
+		startColumn  int
+		endColumn    int
+	}{
+		{token.LET, 1, 1, 4},
+		{token.IDENT, 1, 5, 6},
+		{token.ASSIGN, 1, 7, 8},
+		{token.INT, 1, 9, 10},
+		{token.SEMICOLON, 1, 10, 11},
+		{token.IDENT, 2, 3, 4},
+		{token.PLUS, 2, 5, 6},
+		{token.STRING, 2, 7, 11},
+		{token.EOF, 2, 11, 11},
+	}
+
+	l := NewWithFile("test.mok", input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		start, end := tok.Span.Start, tok.Span.End
+		if start.File != "test.mok" {
+			t.Errorf("tests[%d] - file wrong. got=%q", i, start.File)
+		}
+		if start.Line != tt.startLine || start.Column != tt.startColumn {
+			t.Errorf("tests[%d] - start wrong. expected=%d:%d, got=%d:%d",
+				i, tt.startLine, tt.startColumn, start.Line, start.Column)
+		}
+		if end.Line != tt.startLine || end.Column != tt.endColumn {
+			t.Errorf("tests[%d] - end wrong. expected=%d:%d, got=%d:%d",
+				i, tt.startLine, tt.endColumn, end.Line, end.Column)
+		}
+	}
+}
+
+func TestAssignmentOperators(t *testing.T) {
+	input := `x = 1; x += 2; x -= 3; x *= 4; x /= 5;`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "x"}, {token.ASSIGN, "="}, {token.INT, "1"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.PLUS_ASSIGN, "+="}, {token.INT, "2"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.MINUS_ASSIGN, "-="}, {token.INT, "3"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.ASTERISK_ASSIGN, "*="}, {token.INT, "4"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.SLASH_ASSIGN, "/="}, {token.INT, "5"}, {token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestLogicalOperators(t *testing.T) {
+	input := `a && b || c & d`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.AND, "&&"},
+		{token.IDENT, "b"},
+		{token.OR, "||"},
+		{token.IDENT, "c"},
+		{token.AMPERSAND, "&"},
+		{token.IDENT, "d"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestOperators(t *testing.T) {
+	input := `a <= b >= c % d ** e & f | g ^ h << i >> j ~k < l > m * n *= o`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"}, {token.LT_EQ, "<="},
+		{token.IDENT, "b"}, {token.GT_EQ, ">="},
+		{token.IDENT, "c"}, {token.PERCENT, "%"},
+		{token.IDENT, "d"}, {token.POWER, "**"},
+		{token.IDENT, "e"}, {token.AMPERSAND, "&"},
+		{token.IDENT, "f"}, {token.PIPE, "|"},
+		{token.IDENT, "g"}, {token.CARET, "^"},
+		{token.IDENT, "h"}, {token.SHIFT_LEFT, "<<"},
+		{token.IDENT, "i"}, {token.SHIFT_RIGHT, ">>"},
+		{token.IDENT, "j"}, {token.TILDE, "~"},
+		{token.IDENT, "k"}, {token.LT, "<"},
+		{token.IDENT, "l"}, {token.GT, ">"},
+		{token.IDENT, "m"}, {token.ASTERISK, "*"},
+		{token.IDENT, "n"}, {token.ASTERISK_ASSIGN, "*="},
+		{token.IDENT, "o"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	input := `42 3.14 .5 1e-9 2E+10 7e3 1.5e2 1. x.y 3e 12n 3nx`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.INT, "42"},
+		{token.FLOAT, "3.14"},
+		{token.FLOAT, ".5"},
+		{token.FLOAT, "1e-9"},
+		{token.FLOAT, "2E+10"},
+		{token.FLOAT, "7e3"},
+		{token.FLOAT, "1.5e2"},
+		{token.INT, "1"},
+		{token.DOT, "."},
+		{token.IDENT, "x"},
+		{token.DOT, "."},
+		{token.IDENT, "y"},
+		{token.ILLEGAL, "3e"},
+		{token.BIGINT, "12n"},
+		{token.ILLEGAL, "3nx"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestIntegerFormats(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedError   string
+	}{
+		{"0x1F", token.INT, "0x1F", ""},
+		{"0XfF", token.INT, "0XfF", ""},
+		{"0o17", token.INT, "0o17", ""},
+		{"0b1010", token.INT, "0b1010", ""},
+		{"1_000_000", token.INT, "1_000_000", ""},
+		{"0x_FF", token.INT, "0x_FF", ""},
+		{"1_000.000_1", token.FLOAT, "1_000.000_1", ""},
+		{"0x10n", token.BIGINT, "0x10n", ""},
+		{"0", token.INT, "0", ""},
+		{"0n", token.BIGINT, "0n", ""},
+		{"0x", token.ILLEGAL, "0x", "1:1: hexadecimal literal has no digits"},
+		{"0b", token.ILLEGAL, "0b", "1:1: binary literal has no digits"},
+		{"12abc", token.ILLEGAL, "12abc", "1:1: invalid character 'a' in decimal literal"},
+		{"0b102", token.ILLEGAL, "0b102", "1:1: invalid digit '2' in binary literal"},
+		{"0o8", token.ILLEGAL, "0o8", "1:1: invalid digit '8' in octal literal"},
+		{"0o_9", token.ILLEGAL, "0o_9", "1:1: invalid digit '9' in octal literal"},
+		{"0b2", token.ILLEGAL, "0b2", "1:1: invalid digit '2' in binary literal"},
+		{"0xg", token.ILLEGAL, "0xg", "1:1: invalid character 'g' in hexadecimal literal"},
+		{"0x_", token.ILLEGAL, "0x_", "1:1: hexadecimal literal has no digits"},
+		{"0x1G", token.ILLEGAL, "0x1G", "1:1: invalid character 'G' in hexadecimal literal"},
+		{"1__0", token.ILLEGAL, "1__0", "1:1: '_' must separate successive digits"},
+		{"1_", token.ILLEGAL, "1_", "1:1: '_' must separate successive digits"},
+		{"1.5_", token.ILLEGAL, "1.5_", "1:1: '_' must separate successive digits"},
+		{"1e", token.ILLEGAL, "1e", "1:1: exponent has no digits"},
+		{"017", token.ILLEGAL, "017", "1:1: leading zeros are not allowed in decimal literals"},
+		{"1.5n", token.ILLEGAL, "1.5n", "1:1: invalid character 'n' in decimal literal"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Errorf("%q - tokentype wrong. expected=%q, got=%q", tt.input, tt.expectedType, tok.Type)
+			continue
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("%q - literal wrong. expected=%q, got=%q", tt.input, tt.expectedLiteral, tok.Literal)
+		}
+		if next := l.NextToken(); next.Type != token.EOF {
+			t.Errorf("%q - expected a single token, got another %s %q", tt.input, next.Type, next.Literal)
+		}
+
+		diagnostics := l.Diagnostics()
+		if tt.expectedError == "" {
+			if len(diagnostics) != 0 {
+				t.Errorf("%q - unexpected diagnostic %q", tt.input, diagnostics[0])
+			}
+			continue
+		}
+		if len(diagnostics) != 1 {
+			t.Errorf("%q - expected 1 diagnostic, got %d", tt.input, len(diagnostics))
+			continue
+		}
+		if diagnostics[0].String() != tt.expectedError {
+			t.Errorf("%q - wrong diagnostic. expected=%q, got=%q", tt.input, tt.expectedError, diagnostics[0])
+		}
+		if diagnostics[0].Span.End.Offset != len(tt.input) {
+			t.Errorf("%q - diagnostic should cover the whole literal, ends at %d", tt.input, diagnostics[0].Span.End.Offset)
+		}
+	}
+}
+
+func TestIllegalCharacter(t *testing.T) {
+	l := New("let x = 5 @ 3;")
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+
+	diagnostics := l.Diagnostics()
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d", len(diagnostics))
+	}
+	if diagnostics[0].Code != diagnostic.IllegalCharacter {
+		t.Errorf("wrong code. got=%s", diagnostics[0].Code)
+	}
+	if diagnostics[0].String() != `1:11: illegal character "@"` {
+		t.Errorf("wrong diagnostic. got=%q", diagnostics[0])
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedError   string
+	}{
+		{`"foo bar"`, token.STRING, "foo bar", ""},
+		{`""`, token.STRING, "", ""},
+		{`"a\nb\tc\rd"`, token.STRING, "a\nb\tc\rd", ""},
+		{`"say \"hi\""`, token.STRING, `say "hi"`, ""},
+		{`"back\\slash"`, token.STRING, `back\slash`, ""},
+		{`"\u{1F600} \u{e9}"`, token.STRING, "\U0001F600 é", ""},
+		{"`raw \\n ${x} \"q\"`", token.STRING, `raw \n ${x} "q"`, ""},
+		{"`line one\nline two`", token.STRING, "line one\nline two", ""},
+		{`"abc`, token.ILLEGAL, `"abc`, "1:1: unterminated string"},
+		{"\"abc\n\"", token.ILLEGAL, `"abc`, "1:1: unterminated string"},
+		{`"abc\`, token.ILLEGAL, `"abc\`, "1:1: unterminated string"},
+		{"`abc\n", token.ILLEGAL, "`abc\n", "1:1: unterminated raw string"},
+		{`"a\qb"`, token.STRING, "ab", `1:3: unknown escape sequence \q`},
+		{`"\u1F600"`, token.STRING, "1F600", `1:2: invalid unicode escape, expected \u{...}`},
+		{`"\u{}"`, token.STRING, "}", `1:2: invalid unicode escape, expected 1 to 6 hex digits between braces`},
+		{`"\u{1234567}"`, token.STRING, "}", `1:2: invalid unicode escape, expected 1 to 6 hex digits between braces`},
+		{`"\u{D800}"`, token.STRING, "", `1:2: \u{D800} is not a valid unicode code point`},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Errorf("%q - tokentype wrong. expected=%q, got=%q", tt.input, tt.expectedType, tok.Type)
+			continue
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Errorf("%q - literal wrong. expected=%q, got=%q", tt.input, tt.expectedLiteral, tok.Literal)
+		}
+
+		diagnostics := l.Diagnostics()
+		if tt.expectedError == "" {
+			if len(diagnostics) != 0 {
+				t.Errorf("%q - unexpected diagnostic %q", tt.input, diagnostics[0])
+			}
+			continue
+		}
+		if len(diagnostics) != 1 {
+			t.Errorf("%q - expected 1 diagnostic, got %d", tt.input, len(diagnostics))
+			continue
+		}
+		if diagnostics[0].String() != tt.expectedError {
+			t.Errorf("%q - wrong diagnostic. expected=%q, got=%q", tt.input, tt.expectedError, diagnostics[0])
+		}
+	}
+}
+
+func TestStringInterpolation(t *testing.T) {
+	input := `"total: ${sum} items" "${a}${ {"k": "}"}["k"] }" "${ "in ${x}" } $5 \${y}"`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.TEMPLATE_HEAD, "total: "},
+		{token.IDENT, "sum"},
+		{token.TEMPLATE_TAIL, " items"},
+		{token.TEMPLATE_HEAD, ""},
+		{token.IDENT, "a"},
+		{token.TEMPLATE_MIDDLE, ""},
+		{token.LBRACE, "{"},
+		{token.STRING, "k"},
+		{token.COLON, ":"},
+		{token.STRING, "}"},
+		{token.RBRACE, "}"},
+		{token.LBRACKET, "["},
+		{token.STRING, "k"},
+		{token.RBRACKET, "]"},
+		{token.TEMPLATE_TAIL, ""},
+		{token.TEMPLATE_HEAD, ""},
+		{token.TEMPLATE_HEAD, "in "},
+		{token.IDENT, "x"},
+		{token.TEMPLATE_TAIL, ""},
+		{token.TEMPLATE_TAIL, " $5 ${y}"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	input := "let größe = \"héllo 😀\";\nπ + größe @ é"
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedPos     string
+	}{
+		{token.LET, "let", "1:1"},
+		{token.IDENT, "größe", "1:5"},
+		{token.ASSIGN, "=", "1:11"},
+		{token.STRING, "héllo 😀", "1:13"},
+		{token.SEMICOLON, ";", "1:22"},
+		{token.IDENT, "π", "2:1"},
+		{token.PLUS, "+", "2:3"},
+		{token.IDENT, "größe", "2:5"},
+		{token.ILLEGAL, "@", "2:11"},
+		{token.IDENT, "é", "2:13"},
+		{token.EOF, "", "2:14"},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+		if tok.Span.Start.String() != tt.expectedPos {
+			t.Fatalf("tests[%d] - position wrong. expected=%s, got=%s",
+				i, tt.expectedPos, tok.Span.Start)
+		}
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	l := New("x \xff y")
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "x"},
+		{token.ILLEGAL, "\xff"},
+		{token.IDENT, "y"},
+		{token.EOF, ""},
+	}
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - wrong token. expected=%s %q, got=%s %q",
+				i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+	}
+
+	diagnostics := l.Diagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].String() != `1:3: invalid UTF-8 encoding "\xff"` {
+		t.Errorf("wrong diagnostics. got=%q", diagnostics)
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading comment
+let x = 10 / 2; // trailing
+/* block /* nested */ still comment */ x /= 2;
+/**/ "// not a comment" /* last */`
+
+	tests := []struct {
+		expectedType     token.TokenType
+		expectedLiteral  string
+		expectedComments []string
+	}{
+		{token.LET, "let", []string{"// leading comment"}},
+		{token.IDENT, "x", nil},
+		{token.ASSIGN, "=", nil},
+		{token.INT, "10", nil},
+		{token.SLASH, "/", nil},
+		{token.INT, "2", nil},
+		{token.SEMICOLON, ";", nil},
+		{token.IDENT, "x", []string{"// trailing", "/* block /* nested */ still comment */"}},
+		{token.SLASH_ASSIGN, "/=", nil},
+		{token.INT, "2", nil},
+		{token.SEMICOLON, ";", nil},
+		{token.STRING, "// not a comment", []string{"/**/"}},
+		{token.EOF, "", []string{"/* last */"}},
+	}
+
+	for _, keep := range []bool{false, true} {
+		l := New(input)
+		l.SetKeepComments(keep)
+
+		for i, tt := range tests {
+			tok := l.NextToken()
+
+			if tok.Type != tt.expectedType {
+				t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+					i, tt.expectedType, tok.Type)
+			}
+			if tok.Literal != tt.expectedLiteral {
+				t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+					i, tt.expectedLiteral, tok.Literal)
+			}
+
+			expected := tt.expectedComments
+			if !keep {
+				expected = nil
+			}
+			if len(tok.Comments) != len(expected) {
+				t.Fatalf("tests[%d] - wrong number of comments (keep=%t). expected=%d, got=%d",
+					i, keep, len(expected), len(tok.Comments))
+			}
+			for j, text := range expected {
+				if tok.Comments[j].Text != text {
+					t.Errorf("tests[%d] - comment %d wrong. expected=%q, got=%q",
+						i, j, text, tok.Comments[j].Text)
+				}
+			}
+		}
+
+		if len(l.Diagnostics()) != 0 {
+			t.Errorf("unexpected diagnostics: %q", l.Diagnostics())
+		}
+	}
+}
+
+func TestCommentPositions(t *testing.T) {
+	l := New("x /* a\nb */ y")
+	l.SetKeepComments(true)
+	l.NextToken()
+	tok := l.NextToken()
+
+	if len(tok.Comments) != 1 {
+		t.Fatalf("expected 1 comment, got %d", len(tok.Comments))
+	}
+	span := tok.Comments[0].Span
+	if span.Start.String() != "1:3" || span.End.String() != "2:5" {
+		t.Errorf("wrong comment span. got=%s-%s", span.Start, span.End)
+	}
+	if tok.Span.Start.String() != "2:6" {
+		t.Errorf("wrong token position. got=%s", tok.Span.Start)
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	l := New("let x = 1; /* open /* nested */ never closed")
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+
+	diagnostics := l.Diagnostics()
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d", len(diagnostics))
+	}
+	if diagnostics[0].Code != diagnostic.UnterminatedComment {
+		t.Errorf("wrong code. got=%s", diagnostics[0].Code)
+	}
+	if diagnostics[0].String() != "1:12: unterminated comment" {
+		t.Errorf("wrong diagnostic. got=%q", diagnostics[0])
+	}
+}
+
+func TestNullOperators(t *testing.T) {
+	input := `null ?? a?.b?[0] ? c`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.NULL, "null"},
+		{token.NULLISH, "??"},
+		{token.IDENT, "a"},
+		{token.OPTIONAL_DOT, "?."},
+		{token.IDENT, "b"},
+		{token.OPTIONAL_LBRACKET, "?["},
+		{token.INT, "0"},
+		{token.RBRACKET, "]"},
+		{token.ILLEGAL, "?"},
+		{token.IDENT, "c"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestTupleToken(t *testing.T) {
+	input := `#(1, #()) # (`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.TUPLE_LPAREN, "#("},
+		{token.INT, "1"},
+		{token.COMMA, ","},
+		{token.TUPLE_LPAREN, "#("},
+		{token.RPAREN, ")"},
+		{token.RPAREN, ")"},
+		{token.ILLEGAL, "#"},
+		{token.LPAREN, "("},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
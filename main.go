@@ -18,11 +18,18 @@ import (
 var (
 	cpuProfile = flag.String("cpuprofile", "", "write CPU profile to file")
 	memProfile = flag.String("memprofile", "", "write heap profile to file")
+	leakyBlock = flag.Bool("leaky-blocks", false, "let inside if/while bodies binds in the enclosing scope (old behaviour)")
+	strictBool = flag.Bool("strict-bool", false, "conditions and ! only accept booleans instead of using truthiness")
+	checked    = flag.Bool("checked-arith", false, "integer overflow is an error instead of wrapping around")
 )
 
 func main() {
 	flag.Parse()
 
+	evaluator.LeakyBlockScope = *leakyBlock
+	evaluator.StrictTruthiness = *strictBool
+	evaluator.CheckedArithmetic = *checked
+
 	if *cpuProfile != "" {
 		f, err := os.Create(*cpuProfile)
 		if err != nil {
@@ -72,13 +79,13 @@ func runFile(filename string) {
 	}
 
 	source := string(bytes)
-	l := lexer.New(source)
+	l := lexer.NewWithFile(filename, source)
 	p := parser.New(l)
 	program := p.ParseProgram()
 
-	if len(p.Errors()) != 0 {
-		for _, e := range p.Errors() {
-			fmt.Println("Parser Error:", e)
+	if len(p.Diagnostics()) != 0 {
+		for _, d := range p.Diagnostics() {
+			fmt.Print(d.Render(source))
 		}
 		os.Exit(1)
 	}
@@ -86,6 +93,11 @@ func runFile(filename string) {
 	env := object.NewEnvironment()
 	result := evaluator.Eval(program, env)
 
+	if err, ok := result.(*object.Error); ok {
+		fmt.Print(err.Traceback())
+		os.Exit(1)
+	}
+
 	if result != nil {
 		fmt.Println(result.Inspect())
 	}
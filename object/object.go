@@ -2,9 +2,13 @@ package object
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"hash/fnv"
+	"math/big"
 	"monkey/ast"
+	"monkey/token"
+	"strconv"
 	"strings"
 )
 
@@ -13,6 +17,8 @@ type ObjectType string
 // all the objects in our tree walking evaluator
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
+	BIGINT_OBJ       = "BIGINT"
 	BOOLEA_OBJ       = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
@@ -22,6 +28,9 @@ const (
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	TUPLE_OBJ        = "TUPLE"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
 )
 
 // Interface for objects
@@ -38,6 +47,30 @@ type Integer struct {
 func (i *Integer) Inspect() string  { return fmt.Sprintf("%d", i.Value) }
 func (i *Integer) Type() ObjectType { return INTEGER_OBJ }
 
+// BigInt object, an integer without size limit
+type BigInt struct {
+	Value *big.Int
+}
+
+func (b *BigInt) Type() ObjectType { return BIGINT_OBJ }
+func (b *BigInt) Inspect() string  { return b.Value.String() + "n" }
+
+// Float object
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+
+// shortest form that reads back as the same float, whole numbers keep a ".0" so 2.0 doesn't look like an integer
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if strings.IndexAny(s, ".eIN") == -1 {
+		s += ".0"
+	}
+	return s
+}
+
 // Boolean Object
 type Boolean struct {
 	Value bool
@@ -60,13 +93,75 @@ type ReturnValue struct {
 func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
 func (rv *ReturnValue) Inspect() string  { return rv.Value.Inspect() }
 
+// Break object, passed up from a break statement to the loop around it
+type Break struct{}
+
+func (b *Break) Type() ObjectType { return BREAK_OBJ }
+func (b *Break) Inspect() string  { return "break" }
+
+// Continue object, passed up from a continue statement to the loop around it
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c *Continue) Inspect() string  { return "continue" }
+
 // Error object
 type Error struct {
 	Message string
+	Pos     token.Position // where in the source the error happened, zero if unknown
+	Stack   []Frame        // calls the error propagated out of, innermost first
+}
+
+// one function call an error passed through on its way up
+type Frame struct {
+	Function string         // name of the called function, "<anonymous>" when it has none
+	Pos      token.Position // where the call happened
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
-func (e *Error) Inspect() string  { return "ERROR: " + e.Message }
+func (e *Error) Inspect() string {
+	if e.Pos.IsValid() {
+		return "ERROR: " + e.Pos.String() + ": " + e.Message
+	}
+	return "ERROR: " + e.Message
+}
+
+// returns the error formatted like a Python traceback, outermost call first
+/*
+Traceback (most recent call last):
+  File "main.mok", line 9, column 1, in <program>
+  File "main.mok", line 5, column 10, in outer
+  File "main.mok", line 2, column 3, in inner
+Error: unknown operator: -BOOLEAN
+*/
+func (e *Error) Traceback() string {
+	var out bytes.Buffer
+
+	out.WriteString("Traceback (most recent call last):\n")
+
+	// each call happened inside the function one frame further out
+	caller := "<program>"
+	for i := len(e.Stack) - 1; i >= 0; i-- {
+		writeTracebackLine(&out, e.Stack[i].Pos, caller)
+		caller = e.Stack[i].Function
+	}
+	writeTracebackLine(&out, e.Pos, caller)
+
+	out.WriteString("Error: " + e.Message + "\n")
+
+	return out.String()
+}
+
+func writeTracebackLine(out *bytes.Buffer, pos token.Position, function string) {
+	out.WriteString("  ")
+	if pos.File != "" {
+		fmt.Fprintf(out, "File %q, ", pos.File)
+	}
+	if pos.IsValid() {
+		fmt.Fprintf(out, "line %d, column %d, ", pos.Line, pos.Column)
+	}
+	out.WriteString("in " + function + "\n")
+}
 
 // returns a new pointer of Environment for 'storing references
 func NewEnvironment() *Environment {
@@ -98,6 +193,18 @@ func (e *Environment) Get(name string) (Object, bool) {
 	return obj, ok
 }
 
+// Updates an existing binding, looking through the enclosing environments for the one that holds it
+// returns false when the variable was never declared
+func (e *Environment) Assign(name string, val Object) (Object, bool) {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			env.store[name] = val
+			return val, true
+		}
+	}
+	return nil, false
+}
+
 // Set the mapping between a variable and value for a Environment
 func (e *Environment) Set(name string, val Object) Object {
 	e.store[name] = val
@@ -109,6 +216,7 @@ type Function struct {
 	Parameters []*ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
+	Name       string // empty for anonymous functions
 }
 
 func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
@@ -155,11 +263,21 @@ type Array struct {
 }
 
 func (ao *Array) Type() ObjectType { return ARRAY_OBJ }
-func (ao *Array) Inspect() string {
+func (ao *Array) Inspect() string  { return ao.inspect(nil) }
+
+// an array that contains itself prints as [...] where it comes round again, printing holds the arrays
+// and hashes that are already being printed further up
+func (ao *Array) inspect(printing map[Object]bool) string {
+	if printing[ao] {
+		return "[...]"
+	}
+	printing = markPrinting(printing, ao)
+	defer delete(printing, ao)
+
 	var out bytes.Buffer
 	elements := []string{}
 	for _, e := range ao.Elements {
-		elements = append(elements, e.Inspect())
+		elements = append(elements, inspect(e, printing))
 	}
 	out.WriteString("[")
 	out.WriteString(strings.Join(elements, ", "))
@@ -167,6 +285,44 @@ func (ao *Array) Inspect() string {
 	return out.String()
 }
 
+// Inspect of obj, arrays and hashes keep track of the ones already being printed
+func inspect(obj Object, printing map[Object]bool) string {
+	switch obj := obj.(type) {
+	case *Array:
+		return obj.inspect(printing)
+	case *Hash:
+		return obj.inspect(printing)
+	}
+	return obj.Inspect()
+}
+
+// adds obj to printing, the map is only made once an array or hash is printed
+func markPrinting(printing map[Object]bool, obj Object) map[Object]bool {
+	if printing == nil {
+		printing = make(map[Object]bool)
+	}
+	printing[obj] = true
+	return printing
+}
+
+// Tuple is a fixed list of hashable values, unlike an array it can't change so it can be a hash key
+type Tuple struct {
+	Elements []Object // all Hashable
+}
+
+func (t *Tuple) Type() ObjectType { return TUPLE_OBJ }
+func (t *Tuple) Inspect() string {
+	var out bytes.Buffer
+	elements := []string{}
+	for _, e := range t.Elements {
+		elements = append(elements, e.Inspect())
+	}
+	out.WriteString("#(")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString(")")
+	return out.String()
+}
+
 //creating hashmaps keys structures
 type HashKey struct {
 	Type  ObjectType
@@ -189,10 +345,172 @@ func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
 
+// a big integer that fits in an int64 hashes like the Integer with the same value, so 1n and 1 are the same key
+func (b *BigInt) HashKey() HashKey {
+	if b.Value.IsInt64() {
+		return HashKey{Type: INTEGER_OBJ, Value: uint64(b.Value.Int64())}
+	}
+	h := fnv.New64a()
+	h.Write([]byte{byte(b.Value.Sign() + 1)})
+	h.Write(b.Value.Bytes())
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
+
 func (s *String) HashKey() HashKey {
+	return HashKey{Type: s.Type(), Value: stringHash(s.Value)}
+}
+
+// combines the hash keys of the elements, so tuples with equal elements hash the same
+func (t *Tuple) HashKey() HashKey {
+	h := fnv.New64a()
+	buf := make([]byte, 8)
+	for _, e := range t.Elements {
+		key := e.(Hashable).HashKey()
+		h.Write([]byte(key.Type))
+		binary.LittleEndian.PutUint64(buf, key.Value)
+		h.Write(buf)
+	}
+	return HashKey{Type: t.Type(), Value: h.Sum64()}
+}
+
+// the hash function behind String.HashKey, a variable so the tests in this package can swap in one
+// that collides. different strings may hash the same, Hash compares the keys themselves to tell them apart
+var stringHash = func(s string) uint64 {
 	h := fnv.New64a()
-	h.Write([]byte(s.Value))
-	return HashKey{Type: s.Type(), Value: h.Sum64()}
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// Equal reports whether a and b hold the same value: numbers by value (1, 1.0 and 1n are equal),
+// strings, booleans and null by content, arrays and tuples element by element and hashes by their
+// pairs in any order. anything else, like a function, is only equal to itself.
+// arrays and hashes that contain themselves are compared without looping forever, two of them are
+// equal when no difference can be found between them
+func Equal(a, b Object) bool {
+	return equal(a, b, nil)
+}
+
+// Equal with the pairs of arrays, tuples and hashes that are already being compared further up,
+// seen is only made once the first of them is reached so comparing plain keys doesn't allocate
+func equal(a, b Object, seen map[[2]Object]bool) bool {
+	if IsNumber(a) && IsNumber(b) {
+		return numbersEqual(a, b)
+	}
+
+	switch a := a.(type) {
+	case *String:
+		b, ok := b.(*String)
+		return ok && a.Value == b.Value
+	case *Boolean:
+		b, ok := b.(*Boolean)
+		return ok && a.Value == b.Value
+	case *Null:
+		_, ok := b.(*Null)
+		return ok
+	case *Array, *Tuple, *Hash:
+		// a pair seen again is part of a cycle, any difference shows up somewhere else in the comparison
+		pair := [2]Object{a, b}
+		if a == b || seen[pair] {
+			return true
+		}
+		if seen == nil {
+			seen = make(map[[2]Object]bool)
+		}
+		seen[pair] = true
+		return containersEqual(a, b, seen)
+	}
+	return a == b
+}
+
+// compares the contents of two arrays, tuples or hashes
+func containersEqual(a, b Object, seen map[[2]Object]bool) bool {
+	switch a := a.(type) {
+	case *Array:
+		b, ok := b.(*Array)
+		return ok && elementsEqual(a.Elements, b.Elements, seen)
+	case *Tuple:
+		b, ok := b.(*Tuple)
+		return ok && elementsEqual(a.Elements, b.Elements, seen)
+	case *Hash:
+		b, ok := b.(*Hash)
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+		for _, pair := range a.entries {
+			value, ok := b.Get(pair.Key.(Hashable))
+			if !ok || !equal(pair.Value, value, seen) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// reports whether both lists have equal elements in the same order
+func elementsEqual(a, b []Object, seen map[[2]Object]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !equal(a[i], b[i], seen) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsNumber reports whether obj is an integer, a big integer or a float
+func IsNumber(obj Object) bool {
+	switch obj.(type) {
+	case *Integer, *Float, *BigInt:
+		return true
+	}
+	return false
+}
+
+// IsInteger reports whether obj is an integer or a big integer
+func IsInteger(obj Object) bool {
+	switch obj.(type) {
+	case *Integer, *BigInt:
+		return true
+	}
+	return false
+}
+
+// integers and big integers are compared exactly, a float on either side turns both into floats
+func numbersEqual(a, b Object) bool {
+	x, ok := BigIntValue(a)
+	y, ok2 := BigIntValue(b)
+	if ok && ok2 {
+		return x.Cmp(y) == 0
+	}
+	return FloatValue(a) == FloatValue(b)
+}
+
+// FloatValue returns the value of a number as a float64, 0 for anything else
+func FloatValue(obj Object) float64 {
+	switch obj := obj.(type) {
+	case *Integer:
+		return float64(obj.Value)
+	case *Float:
+		return obj.Value
+	case *BigInt:
+		f, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return f
+	}
+	return 0
+}
+
+// BigIntValue returns the value of an Integer or BigInt as a big.Int, the result must not be modified
+func BigIntValue(obj Object) (*big.Int, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return big.NewInt(obj.Value), true
+	case *BigInt:
+		return obj.Value, true
+	}
+	return nil, false
 }
 
 type HashPair struct {
@@ -200,17 +518,77 @@ type HashPair struct {
 	Value Object
 }
 
+// Hash keeps its pairs in the order the keys were first added, so printing and iterating over it
+// give the same result on every run. the zero value is an empty hash ready to use
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	entries []HashPair // pairs in insertion order
+
+	// positions in entries of the keys with each HashKey, a bucket holds more than one
+	// position only when different keys hash the same
+	buckets map[HashKey][]int
 }
 
+// returns an empty hash
+func NewHash() *Hash {
+	return &Hash{buckets: make(map[HashKey][]int)}
+}
+
+// returns the position in entries of key, whose HashKey is hashed
+func (h *Hash) find(hashed HashKey, key Hashable) (int, bool) {
+	for _, i := range h.buckets[hashed] {
+		if Equal(h.entries[i].Key, key) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// returns the value stored for key
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	i, ok := h.find(key.HashKey(), key)
+	if !ok {
+		return nil, false
+	}
+	return h.entries[i].Value, true
+}
+
+// stores value for key, a key that is already there keeps its place in the order
+func (h *Hash) Set(key Hashable, value Object) {
+	hashed := key.HashKey()
+	if i, ok := h.find(hashed, key); ok {
+		h.entries[i].Value = value
+		return
+	}
+	if h.buckets == nil {
+		h.buckets = make(map[HashKey][]int)
+	}
+	h.buckets[hashed] = append(h.buckets[hashed], len(h.entries))
+	h.entries = append(h.entries, HashPair{Key: key, Value: value})
+}
+
+// returns the number of pairs
+func (h *Hash) Len() int { return len(h.entries) }
+
+// returns the pairs in insertion order, the slice must not be modified
+func (h *Hash) Pairs() []HashPair { return h.entries }
+
 func (h *Hash) Type() ObjectType { return HASH_OBJ }
-func (h *Hash) Inspect() string {
+func (h *Hash) Inspect() string  { return h.inspect(nil) }
+
+// like Array.inspect, a hash that contains itself prints as {...}. keys can't contain anything
+// that changes, so only the values can lead back to the hash
+func (h *Hash) inspect(printing map[Object]bool) string {
+	if printing[h] {
+		return "{...}"
+	}
+	printing = markPrinting(printing, h)
+	defer delete(printing, h)
+
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for _, pair := range h.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	for _, pair := range h.entries {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), inspect(pair.Value, printing)))
 	}
 
 	out.WriteString("{")
@@ -221,5 +599,6 @@ func (h *Hash) Inspect() string {
 }
 
 type Hashable interface {
+	Object
 	HashKey() HashKey
 }
\ No newline at end of file
@@ -1,6 +1,10 @@
 package object
 
-import "testing"
+import (
+	"math"
+	"math/big"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -18,3 +22,257 @@ func TestStringHashKey(t *testing.T) {
 		t.Errorf("strings with different content have same hash keys")
 	}
 }
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		value    float64
+		expected string
+	}{
+		{3.14, "3.14"},
+		{2, "2.0"},
+		{-0.5, "-0.5"},
+		{1e21, "1e+21"},
+		{1e-9, "1e-09"},
+		{math.Inf(1), "+Inf"},
+		{math.NaN(), "NaN"},
+	}
+
+	for _, tt := range tests {
+		f := &Float{Value: tt.value}
+		if f.Inspect() != tt.expected {
+			t.Errorf("Inspect wrong. expected=%q, got=%q", tt.expected, f.Inspect())
+		}
+	}
+}
+
+func TestBigIntHashKey(t *testing.T) {
+	small := &BigInt{Value: big.NewInt(42)}
+	if small.HashKey() != (&Integer{Value: 42}).HashKey() {
+		t.Errorf("big integer that fits in int64 should hash like the Integer")
+	}
+
+	huge1, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	huge2, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	if (&BigInt{Value: huge1}).HashKey() != (&BigInt{Value: huge2}).HashKey() {
+		t.Errorf("big integers with same value have different hash keys")
+	}
+	if (&BigInt{Value: huge1}).HashKey() == (&BigInt{Value: new(big.Int).Neg(huge1)}).HashKey() {
+		t.Errorf("big integers with different sign have the same hash key")
+	}
+}
+
+func TestHashSetGet(t *testing.T) {
+	h := NewHash()
+	h.Set(&String{Value: "b"}, &Integer{Value: 1})
+	h.Set(&Integer{Value: 7}, &Integer{Value: 2})
+	h.Set(&String{Value: "a"}, &Integer{Value: 3})
+	h.Set(&String{Value: "b"}, &Integer{Value: 4})
+
+	if h.Len() != 3 {
+		t.Fatalf("wrong length. expected=3, got=%d", h.Len())
+	}
+
+	value, ok := h.Get(&String{Value: "b"})
+	if !ok || value.(*Integer).Value != 4 {
+		t.Errorf("wrong value for b. got=%v", value)
+	}
+	if _, ok := h.Get(&String{Value: "7"}); ok {
+		t.Errorf("string key 7 found, only the integer 7 was set")
+	}
+
+	if h.Inspect() != "{b: 4, 7: 2, a: 3}" {
+		t.Errorf("pairs not in insertion order. got=%s", h.Inspect())
+	}
+}
+
+func TestHashZeroValue(t *testing.T) {
+	h := &Hash{}
+	if _, ok := h.Get(&String{Value: "a"}); ok {
+		t.Errorf("empty hash found a key")
+	}
+
+	h.Set(&String{Value: "a"}, &Integer{Value: 1})
+	value, ok := h.Get(&String{Value: "a"})
+	if !ok || value.(*Integer).Value != 1 {
+		t.Errorf("wrong value for a. got=%v", value)
+	}
+	if h.Len() != 1 || h.Inspect() != "{a: 1}" {
+		t.Errorf("wrong hash. got=%s", h.Inspect())
+	}
+}
+
+func TestTupleHashKey(t *testing.T) {
+	pair1 := &Tuple{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	pair2 := &Tuple{Elements: []Object{&BigInt{Value: big.NewInt(1)}, &String{Value: "a"}}}
+	swapped := &Tuple{Elements: []Object{&String{Value: "a"}, &Integer{Value: 1}}}
+	nested := &Tuple{Elements: []Object{pair1}}
+
+	if pair1.HashKey() != pair2.HashKey() {
+		t.Errorf("tuples with equal elements have different hash keys")
+	}
+	if pair1.HashKey() == swapped.HashKey() {
+		t.Errorf("tuples with elements in a different order have the same hash key")
+	}
+	if pair1.HashKey() == nested.HashKey() {
+		t.Errorf("a tuple and a tuple holding it have the same hash key")
+	}
+	if pair1.Inspect() != "#(1, a)" {
+		t.Errorf("wrong Inspect. got=%s", pair1.Inspect())
+	}
+
+	h := NewHash()
+	h.Set(pair1, &Integer{Value: 1})
+	if _, ok := h.Get(pair2); !ok {
+		t.Errorf("equal tuple not found in hash")
+	}
+	if _, ok := h.Get(swapped); ok {
+		t.Errorf("different tuple found in hash")
+	}
+}
+
+func TestHashCollisions(t *testing.T) {
+	defer func(original func(string) uint64) { stringHash = original }(stringHash)
+	stringHash = func(string) uint64 { return 42 }
+
+	a := &String{Value: "a"}
+	b := &String{Value: "b"}
+	if a.HashKey() != b.HashKey() {
+		t.Fatalf("hash function was not swapped in")
+	}
+
+	h := NewHash()
+	h.Set(a, &Integer{Value: 1})
+	h.Set(b, &Integer{Value: 2})
+	h.Set(&String{Value: "a"}, &Integer{Value: 3})
+
+	if h.Len() != 2 {
+		t.Fatalf("colliding keys were merged. expected 2 pairs, got=%d", h.Len())
+	}
+
+	tests := []struct {
+		key      string
+		expected int64
+		found    bool
+	}{
+		{"a", 3, true},
+		{"b", 2, true},
+		{"c", 0, false},
+	}
+	for _, tt := range tests {
+		value, ok := h.Get(&String{Value: tt.key})
+		if ok != tt.found {
+			t.Errorf("key %q found=%t, expected %t", tt.key, ok, tt.found)
+			continue
+		}
+		if ok && value.(*Integer).Value != tt.expected {
+			t.Errorf("wrong value for %q. expected=%d, got=%d", tt.key, tt.expected, value.(*Integer).Value)
+		}
+	}
+
+	if keys := h.Inspect(); keys != "{a: 3, b: 2}" {
+		t.Errorf("colliding keys lost their order. got=%s", keys)
+	}
+
+	// tuples of colliding strings collide as well
+	pairA := &Tuple{Elements: []Object{a, &Integer{Value: 1}}}
+	pairB := &Tuple{Elements: []Object{b, &Integer{Value: 1}}}
+	h.Set(pairA, &Integer{Value: 4})
+	h.Set(pairB, &Integer{Value: 5})
+	if value, ok := h.Get(&Tuple{Elements: []Object{&String{Value: "b"}, &Integer{Value: 1}}}); !ok || value.(*Integer).Value != 5 {
+		t.Errorf("wrong value for #(b, 1). got=%v", value)
+	}
+}
+
+func TestHashIntegerKeys(t *testing.T) {
+	h := NewHash()
+	h.Set(&Integer{Value: 1}, &String{Value: "one"})
+
+	big1 := &BigInt{Value: big.NewInt(1)}
+	if value, ok := h.Get(big1); !ok || value.Inspect() != "one" {
+		t.Errorf("1n should find the value stored for 1. got=%v", value)
+	}
+
+	h.Set(big1, &String{Value: "uno"})
+	if h.Len() != 1 {
+		t.Errorf("1n and 1 should be the same key. got %d pairs", h.Len())
+	}
+	if _, ok := h.Get(&Boolean{Value: true}); ok {
+		t.Errorf("true should not find the value stored for 1")
+	}
+}
+
+func TestEqual(t *testing.T) {
+	one := &Integer{Value: 1}
+	fn := &Builtin{}
+
+	cyclic := func(extra Object) *Array {
+		arr := &Array{Elements: []Object{one, nil}}
+		arr.Elements[1] = arr
+		if extra != nil {
+			arr.Elements = append(arr.Elements, extra)
+		}
+		return arr
+	}
+
+	selfHash := func(value Object) *Hash {
+		h := NewHash()
+		h.Set(&String{Value: "self"}, h)
+		h.Set(&String{Value: "v"}, value)
+		return h
+	}
+
+	hash := func(pairs ...Object) *Hash {
+		h := NewHash()
+		for i := 0; i < len(pairs); i += 2 {
+			h.Set(pairs[i].(Hashable), pairs[i+1])
+		}
+		return h
+	}
+
+	self := cyclic(nil)
+
+	tests := []struct {
+		name     string
+		a, b     Object
+		expected bool
+	}{
+		{"integers", &Integer{Value: 1}, &Integer{Value: 1}, true},
+		{"different integers", &Integer{Value: 1}, &Integer{Value: 2}, false},
+		{"integer and float", &Integer{Value: 1}, &Float{Value: 1}, true},
+		{"integer and big integer", &Integer{Value: 1}, &BigInt{Value: big.NewInt(1)}, true},
+		{"NaN", &Float{Value: math.NaN()}, &Float{Value: math.NaN()}, false},
+		{"strings", &String{Value: "a"}, &String{Value: "a"}, true},
+		{"string and integer", &String{Value: "1"}, one, false},
+		{"booleans", &Boolean{Value: true}, &Boolean{Value: true}, true},
+		{"nulls", &Null{}, &Null{}, true},
+		{"null and false", &Null{}, &Boolean{Value: false}, false},
+		{"arrays", &Array{Elements: []Object{one, &String{Value: "a"}}}, &Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}, true},
+		{"arrays in a different order", &Array{Elements: []Object{one, &String{Value: "a"}}}, &Array{Elements: []Object{&String{Value: "a"}, one}}, false},
+		{"array and tuple", &Array{Elements: []Object{one}}, &Tuple{Elements: []Object{one}}, false},
+		{"hashes in any order", hash(&String{Value: "a"}, one, &String{Value: "b"}, &Array{}), hash(&String{Value: "b"}, &Array{}, &String{Value: "a"}, one), true},
+		{"hashes with different values", hash(&String{Value: "a"}, one), hash(&String{Value: "a"}, &Integer{Value: 2}), false},
+		{"same function", fn, fn, true},
+		{"different functions", fn, &Builtin{}, false},
+		{"cyclic arrays", cyclic(nil), cyclic(nil), true},
+		{"cyclic arrays that differ", cyclic(one), cyclic(&Integer{Value: 2}), false},
+		{"cyclic array and itself", self, self.Elements[1], true},
+		{"cyclic hashes", selfHash(one), selfHash(&Integer{Value: 1}), true},
+		{"cyclic hashes that differ", selfHash(one), selfHash(&String{Value: "1"}), false},
+	}
+
+	if got := cyclic(nil).Inspect(); got != "[1, [...]]" {
+		t.Errorf("wrong Inspect of a cyclic array. got=%s", got)
+	}
+	if got := selfHash(one).Inspect(); got != "{self: {...}, v: 1}" {
+		t.Errorf("wrong Inspect of a cyclic hash. got=%s", got)
+	}
+
+	for _, tt := range tests {
+		if got := Equal(tt.a, tt.b); got != tt.expected {
+			t.Errorf("%s: Equal(a, b) = %t, expected %t", tt.name, got, tt.expected)
+		}
+		if got := Equal(tt.b, tt.a); got != tt.expected {
+			t.Errorf("%s: Equal(b, a) = %t, expected %t", tt.name, got, tt.expected)
+		}
+	}
+}
@@ -2,10 +2,14 @@ package parser
 
 import (
 	"fmt"
+	"math/big"
 	"monkey/ast"
+	"monkey/diagnostic"
 	"monkey/lexer"
 	"monkey/token"
+	"sort"
 	"strconv"
+	"strings"
 )
 
 type (
@@ -14,8 +18,11 @@ type (
 )
 
 type Parser struct {
-	l      *lexer.Lexer
-	errors []string
+	l           *lexer.Lexer
+	diagnostics []diagnostic.Diagnostic
+	panicking   bool // set after an error until the parser has resynchronized, extra errors are dropped meanwhile
+	loopDepth   int  // number of while loops around the current token, in the current function
+	nesting     int  // brackets, braces and parentheses opened before the current token and not closed yet
 
 	curToken  token.Token
 	peekToken token.Token
@@ -37,8 +44,8 @@ func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
 
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
-		l:      l,
-		errors: []string{},
+		l:           l,
+		diagnostics: []diagnostic.Diagnostic{},
 	}
 
 	//for prefix expression
@@ -46,16 +53,23 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParserFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(token.BIGINT, p.parseBigIntLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.TILDE, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.NULL, p.parseNull)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionaLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.TEMPLATE_HEAD, p.parseInterpolatedString)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.TUPLE_LPAREN, p.parseTupleLiteral)
 	p.registerPrefix(token.LBRACE,p.parseHashLiteral)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 
 	//for infix expression
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
@@ -67,8 +81,28 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.GT_EQ, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
+	p.registerInfix(token.POWER, p.parseInfixExpression)
+	p.registerInfix(token.AMPERSAND, p.parseInfixExpression)
+	p.registerInfix(token.PIPE, p.parseInfixExpression)
+	p.registerInfix(token.CARET, p.parseInfixExpression)
+	p.registerInfix(token.SHIFT_LEFT, p.parseInfixExpression)
+	p.registerInfix(token.SHIFT_RIGHT, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.NULLISH, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.OPTIONAL_LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseDotExpression)
+	p.registerInfix(token.OPTIONAL_DOT, p.parseDotExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.ASTERISK_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.SLASH_ASSIGN, p.parseAssignExpression)
 
 	p.nextToken()
 	p.nextToken()
@@ -76,15 +110,116 @@ func New(l *lexer.Lexer) *Parser {
 	return p
 }
 
-// return slice of errors
+// return slice of errors, one line per diagnostic
 func (p *Parser) Errors() []string {
-	return p.errors
+	errors := []string{}
+	for _, d := range p.Diagnostics() {
+		errors = append(errors, d.String())
+	}
+	return errors
+}
+
+// returns everything the lexer and the parser found wrong with the input, in source order
+func (p *Parser) Diagnostics() []diagnostic.Diagnostic {
+	all := append(append([]diagnostic.Diagnostic{}, p.l.Diagnostics()...), p.diagnostics...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Span.Start.Offset < all[j].Span.Start.Offset
+	})
+	return all
+}
+
+// records a diagnostic and puts the parser in panic mode, nothing is recorded while already panicking.
+// problems caused by an ILLEGAL token only start the panic, the lexer already reported that token
+func (p *Parser) report(d diagnostic.Diagnostic) {
+	if p.panicking {
+		return
+	}
+	if d.Found == token.ILLEGAL {
+		p.panicking = true
+		return
+	}
+	p.panicking = true
+	p.diagnostics = append(p.diagnostics, d)
+}
+
+// records an error diagnostic covering the given token
+func (p *Parser) addError(code diagnostic.Code, tok token.Token, msg string, hints ...string) {
+	p.report(diagnostic.Diagnostic{
+		Severity: diagnostic.Error,
+		Code:     code,
+		Span:     tok.Span,
+		Message:  msg,
+		Found:    tok.Type,
+		Hints:    hints,
+	})
 }
 
-// adds a string which displays the type which was expected(according to the parameter) to the parser's errorr slice
+// adds a diagnostic which displays the type which was expected(according to the parameter) to the parser's diagnostics
 func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.report(diagnostic.Diagnostic{
+		Severity: diagnostic.Error,
+		Code:     diagnostic.UnexpectedToken,
+		Span:     p.peekToken.Span,
+		Message:  fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type),
+		Expected: []token.TokenType{t},
+		Found:    p.peekToken.Type,
+	})
+}
+
+// skips tokens after an error until a point where a new statement can start: just past a ';',
+// at a '}' closing the current block, or at let, return, while or fn.
+// level is the nesting where the broken statement started, the brackets it opened before the error
+// are skipped up to their closers first, so the } of {"a" 1} doesn't look like the end of a block.
+// braces opened while skipping are skipped as a whole
+func (p *Parser) synchronize(level int) {
+	p.panicking = false
+
+	// a ; or a keyword outside the groups opened while skipping means the statement's own bracket
+	// was never closed, like the ( in if (x { y } let a = 1, the normal rules take over from there
+	floor := p.nesting
+	skipped := false
+	for p.nesting > level {
+		if p.nesting < floor {
+			floor = p.nesting
+		}
+		if p.nesting == floor {
+			switch p.curToken.Type {
+			case token.EOF, token.SEMICOLON, token.LET, token.RETURN, token.WHILE:
+				floor = -1
+			}
+		}
+		if floor == -1 {
+			break
+		}
+		p.nextToken()
+		skipped = true
+	}
+
+	depth := 0
+
+	for moved := skipped; ; moved = true {
+		switch p.curToken.Type {
+		case token.EOF:
+			return
+		case token.SEMICOLON:
+			if depth == 0 {
+				p.nextToken()
+				return
+			}
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth == 0 {
+				return
+			}
+			depth--
+		case token.LET, token.RETURN, token.WHILE, token.FUNCTION:
+			if moved && depth == 0 {
+				return
+			}
+		}
+		p.nextToken()
+	}
 }
 
 // returns a boolean value after checking if the next token is as expected(according to the parameter)
@@ -100,6 +235,12 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 
 // increments parser's curtoken and parser's peektoken
 func (p *Parser) nextToken() {
+	switch p.curToken.Type {
+	case token.LPAREN, token.LBRACKET, token.LBRACE, token.TUPLE_LPAREN, token.TEMPLATE_HEAD:
+		p.nesting++
+	case token.RPAREN, token.RBRACKET, token.RBRACE, token.TEMPLATE_TAIL:
+		p.nesting--
+	}
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
 }
@@ -110,7 +251,16 @@ func (p *Parser) ParseProgram() *ast.Program {
 	program.Statements = []ast.Statement{}
 
 	for p.curToken.Type != token.EOF {
+		level := p.nesting
 		stmt := p.parseStatement()
+		if p.panicking {
+			p.synchronize(level)
+			// a '}' with no block around it, step over it
+			if p.curTokenIs(token.RBRACE) {
+				p.nextToken()
+			}
+			continue
+		}
 		if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
@@ -129,6 +279,8 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseReturnStatement()
 	case token.WHILE:
 		return  p.parseWhileStatement()
+	case token.BREAK, token.CONTINUE:
+		return p.parseLoopControlStatement()
 	default:
 		return p.parseExpressionStatment()
 	}
@@ -154,6 +306,11 @@ func (p *Parser) parseLetStatement() ast.Statement {
 
 	stmt.Value = p.parseExpression(LOWEST)
 
+	// let the function know its name so it shows up in stack traces
+	if fl, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		fl.Name = stmt.Name.Value
+	}
+
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
@@ -178,7 +335,7 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 
 	stmt.ReturnValue = p.parseExpression(LOWEST)
 
-	for !p.curTokenIs(token.SEMICOLON) {
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -239,7 +396,11 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
 	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	if t == token.EOF {
+		p.addError(diagnostic.NoPrefixParseFn, p.curToken, msg, "the input ended in the middle of an expression")
+		return
+	}
+	p.addError(diagnostic.NoPrefixParseFn, p.curToken, msg)
 }
 
 func (p *Parser) peekPrecedence() int {
@@ -265,11 +426,20 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // x = y or x += y
+	NULLISH     // ??
+	LOGICAL_OR  // ||
+	LOGICAL_AND // &&
 	EQUALS      // ==
 	LESSGREATER // > or <
+	BITWISE_OR  // |
+	BITWISE_XOR // ^
+	BITWISE_AND // &
+	SHIFT       // << or >>
 	SUM         // +
-	PRODUCT     // *
+	PRODUCT     // * or / or %
 	PREFIX      // -X or !X
+	POWER       // ** (binds tighter than prefix, -2 ** 2 is -(2 ** 2))
 	CALL        // myFunction(X)
 	INDEX
 )
@@ -293,7 +463,44 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(diagnostic.InvalidInteger, p.curToken, msg,
+			"integers must fit in 64 bits, add an n suffix for a big integer: "+p.curToken.Literal+"n")
+		return nil
+	}
+
+	lit.Value = value
+	return lit
+}
+
+// for ILLEGAL tokens, the lexer has reported them so the parser just resynchronizes
+func (p *Parser) parseIllegal() ast.Expression {
+	p.panicking = true
+	return nil
+}
+
+// for big integers like 123n, they have no size limit
+func (p *Parser) parseBigIntLiteral() ast.Expression {
+	lit := &ast.BigIntLiteral{Token: p.curToken}
+
+	value, ok := new(big.Int).SetString(strings.TrimSuffix(p.curToken.Literal, "n"), 0)
+	if !ok {
+		msg := fmt.Sprintf("could not parse %q as big integer", p.curToken.Literal)
+		p.addError(diagnostic.InvalidInteger, p.curToken, msg)
+		return nil
+	}
+
+	lit.Value = value
+	return lit
+}
+
+// for floats like 3.14, .5 or 1e-9
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
+		p.addError(diagnostic.InvalidFloat, p.curToken, msg, "floats must fit in 64 bits")
 		return nil
 	}
 
@@ -312,11 +519,56 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 		Left:     left,
 	}
 	precedence := p.curPrecedence()
+	// ** is right associative, 2 ** 3 ** 2 is 2 ** (3 ** 2)
+	if expression.Operator == "**" {
+		precedence--
+	}
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
 	return expression
 }
 
+// assignment expression, the target has already been parsed as the left side
+/*
+x = 5;
+x += y * 2;
+a = b = 0; (right associative, a = (b = 0))
+*/
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		Token:    p.curToken,
+		Target:   target,
+		Operator: p.curToken.Literal,
+	}
+
+	if !isAssignable(target) && target != nil {
+		p.report(diagnostic.Diagnostic{
+			Severity: diagnostic.Error,
+			Code:     diagnostic.InvalidAssign,
+			Span:     token.Span{Start: target.Pos(), End: target.End()},
+			Message:  fmt.Sprintf("cannot assign to %s", target.String()),
+			Found:    p.curToken.Type,
+			Hints:    []string{"only variables and elements like a[i] or h[\"key\"] can be assigned to"},
+		})
+	}
+
+	p.nextToken()
+	// one below ASSIGN so that a following = binds to the right
+	expression.Value = p.parseExpression(ASSIGN - 1)
+	return expression
+}
+
+// variables and index expressions can be assigned to, a?[i] can't since there may be nothing to assign into
+func isAssignable(target ast.Expression) bool {
+	switch target := target.(type) {
+	case *ast.Identifier:
+		return true
+	case *ast.IndexExpression:
+		return !target.Optional
+	}
+	return false
+}
+
 // this fucntion gives the precedence of the current operator using the map
 func (p *Parser) curPrecedence() int {
 	if p, ok := precedences[p.curToken.Type]; ok {
@@ -326,16 +578,40 @@ func (p *Parser) curPrecedence() int {
 }
 
 var precedences = map[token.TokenType]int{
-	token.EQ:       EQUALS,
-	token.NOT_EQ:   EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.LPAREN:   CALL,
-	token.LBRACKET: INDEX,
+	token.ASSIGN:            ASSIGN,
+	token.PLUS_ASSIGN:       ASSIGN,
+	token.MINUS_ASSIGN:      ASSIGN,
+	token.ASTERISK_ASSIGN:   ASSIGN,
+	token.SLASH_ASSIGN:      ASSIGN,
+	token.NULLISH:           NULLISH,
+	token.OR:                LOGICAL_OR,
+	token.AND:               LOGICAL_AND,
+	token.EQ:                EQUALS,
+	token.NOT_EQ:            EQUALS,
+	token.LT:                LESSGREATER,
+	token.GT:                LESSGREATER,
+	token.LT_EQ:             LESSGREATER,
+	token.GT_EQ:             LESSGREATER,
+	token.PIPE:              BITWISE_OR,
+	token.CARET:             BITWISE_XOR,
+	token.AMPERSAND:         BITWISE_AND,
+	token.SHIFT_LEFT:        SHIFT,
+	token.SHIFT_RIGHT:       SHIFT,
+	token.PLUS:              SUM,
+	token.MINUS:             SUM,
+	token.SLASH:             PRODUCT,
+	token.ASTERISK:          PRODUCT,
+	token.PERCENT:           PRODUCT,
+	token.POWER:             POWER,
+	token.LPAREN:            CALL,
+	token.LBRACKET:          INDEX,
+	token.OPTIONAL_LBRACKET: INDEX,
+	token.DOT:               INDEX,
+	token.OPTIONAL_DOT:      INDEX,
+}
+
+func (p *Parser) parseNull() ast.Expression {
+	return &ast.Null{Token: p.curToken}
 }
 
 func (p *Parser) parseBoolean() ast.Expression {
@@ -384,14 +660,24 @@ func (p *Parser) parseIfExpression() ast.Expression {
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
+
+	// when the statement around this block is already broken, leave the recovery to it
+	inherited := p.panicking
+
 	p.nextToken()
 	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
+		level := p.nesting
 		stmt := p.parseStatement()
+		if p.panicking && !inherited {
+			p.synchronize(level)
+			continue
+		}
 		if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
 		p.nextToken()
 	}
+	block.Rbrace = p.curToken
 	return block
 }
 
@@ -408,7 +694,11 @@ func (p *Parser) parseFunctionaLiteral() ast.Expression {
 		return nil
 	}
 
+	// a loop around the function doesn't count inside its body
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
 	lit.Body = p.parseBlockStatement()
+	p.loopDepth = loopDepth
 
 	return lit
 }
@@ -448,6 +738,7 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
 	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	exp.Rparen = p.curToken
 	return exp
 }
 
@@ -480,15 +771,61 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+// for strings with embedded expressions like "total: ${sum} items"
+func (p *Parser) parseInterpolatedString() ast.Expression {
+	str := &ast.InterpolatedString{Token: p.curToken}
+	str.Parts = append(str.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+
+	for {
+		p.nextToken()
+		if p.curTokenIs(token.TEMPLATE_MIDDLE) || p.curTokenIs(token.TEMPLATE_TAIL) {
+			p.addError(diagnostic.NoPrefixParseFn, p.curToken, "expected an expression inside ${}")
+			return nil
+		}
+		str.Parts = append(str.Parts, p.parseExpression(LOWEST))
+
+		switch p.peekToken.Type {
+		case token.TEMPLATE_MIDDLE:
+			p.nextToken()
+			str.Parts = append(str.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+		case token.TEMPLATE_TAIL:
+			p.nextToken()
+			str.Parts = append(str.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+			str.Tail = p.curToken
+			return str
+		default:
+			p.report(diagnostic.Diagnostic{
+				Severity: diagnostic.Error,
+				Code:     diagnostic.UnexpectedToken,
+				Span:     p.peekToken.Span,
+				Message:  fmt.Sprintf("expected } to close the ${ in the string, got %s instead", p.peekToken.Type),
+				Expected: []token.TokenType{token.RBRACE},
+				Found:    p.peekToken.Type,
+			})
+			return nil
+		}
+	}
+}
+
 // parsing for array literals
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
 
 	array.Elements = p.parseExpressionList(token.RBRACKET)
+	array.Rbracket = p.curToken
 
 	return array
 }
 
+func (p *Parser) parseTupleLiteral() ast.Expression {
+	tuple := &ast.TupleLiteral{Token: p.curToken}
+
+	tuple.Elements = p.parseExpressionList(token.RPAREN)
+	tuple.Rparen = p.curToken
+
+	return tuple
+}
+
 func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	list := []ast.Expression{}
 
@@ -517,7 +854,7 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 // implementing infix function for indexing of array literals
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 
-	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left, Optional: p.curTokenIs(token.OPTIONAL_LBRACKET)}
 
 	p.nextToken()
 
@@ -526,6 +863,20 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
+	exp.Rbracket = p.curToken
+
+	return exp
+}
+
+// for a.b and a?.b, the same as a["b"] and a?["b"]
+func (p *Parser) parseDotExpression(left ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left, Optional: p.curTokenIs(token.OPTIONAL_DOT)}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Index = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+	exp.Rbracket = p.curToken
 
 	return exp
 }
@@ -546,6 +897,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		value:= p.parseExpression(LOWEST)
 
 		hash.Pairs[key] = value
+		hash.Keys = append(hash.Keys, key)
 
 		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
 			return nil
@@ -556,6 +908,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 	if !p.expectPeek(token.RBRACE) {
 		return nil
 	}
+	hash.Rbrace = p.curToken
 
 	return hash
 }
@@ -581,7 +934,28 @@ func (p *Parser) parseWhileStatement() ast.Statement {
         return nil
     }
 
+    p.loopDepth++
     statement.Body = p.parseBlockStatement()
+    p.loopDepth--
 
     return statement
+}
+
+// break and continue statements, only allowed inside a while loop
+func (p *Parser) parseLoopControlStatement() ast.Statement {
+	tok := p.curToken
+
+	if p.loopDepth == 0 {
+		p.addError(diagnostic.OutsideLoop, tok, fmt.Sprintf("%s outside of a loop", tok.Literal))
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	if tok.Type == token.BREAK {
+		return &ast.BreakStatement{Token: tok}
+	}
+	return &ast.ContinueStatement{Token: tok}
 }
\ No newline at end of file
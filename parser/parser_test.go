@@ -3,7 +3,9 @@ package parser
 import (
 	"fmt"
 	"monkey/ast"
+	"monkey/diagnostic"
 	"monkey/lexer"
+	"monkey/token"
 	"testing"
 )
 
@@ -563,6 +565,38 @@ func TestStringLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestInterpolatedStringParsing(t *testing.T) {
+	input := `"total: ${a + b * 2} items, ${len(x)}!";`
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	str, ok := stmt.Expression.(*ast.InterpolatedString)
+	if !ok {
+		t.Fatalf("exp not *ast.InterpolatedString. got=%T", stmt.Expression)
+	}
+	if len(str.Parts) != 5 {
+		t.Fatalf("wrong number of parts. expected=5, got=%d", len(str.Parts))
+	}
+	for i, text := range []string{"total: ", " items, ", "!"} {
+		literal, ok := str.Parts[i*2].(*ast.StringLiteral)
+		if !ok {
+			t.Fatalf("parts[%d] not *ast.StringLiteral. got=%T", i*2, str.Parts[i*2])
+		}
+		if literal.Value != text {
+			t.Errorf("parts[%d] wrong. expected=%q, got=%q", i*2, text, literal.Value)
+		}
+	}
+	if str.String() != `"total: ${(a + (b * 2))} items, ${len(x)}!"` {
+		t.Errorf("str.String() wrong. got=%s", str.String())
+	}
+	if str.End().Offset != len(input)-1 {
+		t.Errorf("str.End() wrong. got=%s", str.End())
+	}
+}
+
 func TestParsingArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
@@ -696,6 +730,102 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"a * [1, 2, 3, 4][b * c] * d",
 			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
 		},
+		{
+			"a <= b == c >= d",
+			"((a <= b) == (c >= d))",
+		},
+		{
+			"a + b % c * d",
+			"(a + ((b % c) * d))",
+		},
+		{
+			"2 ** 3 ** 2",
+			"(2 ** (3 ** 2))",
+		},
+		{
+			"-2 ** 2",
+			"(-(2 ** 2))",
+		},
+		{
+			"a * b ** c",
+			"(a * (b ** c))",
+		},
+		{
+			"a | b ^ c & d",
+			"(a | (b ^ (c & d)))",
+		},
+		{
+			"a & b << 1 + c",
+			"(a & (b << (1 + c)))",
+		},
+		{
+			"a | b == c",
+			"((a | b) == c)",
+		},
+		{
+			"~a & b >> c",
+			"((~a) & (b >> c))",
+		},
+		{
+			"a || b && c",
+			"(a || (b && c))",
+		},
+		{
+			"a && b || c && d",
+			"((a && b) || (c && d))",
+		},
+		{
+			"a == 1 && b < 2 || !c",
+			"(((a == 1) && (b < 2)) || (!c))",
+		},
+		{
+			"x = a || b",
+			"(x = (a || b))",
+		},
+		{
+			"a ?? b || c",
+			"(a ?? (b || c))",
+		},
+		{
+			"a ?? b ?? c",
+			"((a ?? b) ?? c)",
+		},
+		{
+			"x = a ?? null",
+			"(x = (a ?? null))",
+		},
+		{
+			"a?.b?[0] + c[1]?.d",
+			"(((a?[b])?[0]) + ((c[1])?[d]))",
+		},
+		{
+			"f(x)?.y",
+			"(f(x)?[y])",
+		},
+		{
+			"cfg.server.port",
+			"((cfg[server])[port])",
+		},
+		{
+			`{"b": 1, "a": 2 + 3}`,
+			"{b:1, a:(2 + 3)}",
+		},
+		{
+			"#(1, 2 + 3)[0]",
+			"(#(1, (2 + 3))[0])",
+		},
+		{
+			"#()",
+			"#()",
+		},
+		{
+			"-a.b * c.d(e)",
+			"((-(a[b])) * (c[d])(e))",
+		},
+		{
+			"s.trim().upper()",
+			"((s[trim])()[upper])()",
+		},
 		{
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
@@ -837,3 +967,308 @@ func TestWhileStatement(t *testing.T) {
 		return
 	}
 }
+
+func TestNodePositions(t *testing.T) {
+	input := `let add = fn(a, b) {
+  a + b
+};
+add(1, [2, 3][0])`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	tests := []struct {
+		node  ast.Node
+		start string
+		end   string
+	}{
+		{program, "1:1", "4:18"},
+		{program.Statements[0], "1:1", "3:2"},
+		{program.Statements[0].(*ast.LetStatement).Value.(*ast.FunctionLiteral).Body, "1:20", "3:2"},
+		{program.Statements[0].(*ast.LetStatement).Value.(*ast.FunctionLiteral).Body.Statements[0], "2:3", "2:8"},
+		{program.Statements[1].(*ast.ExpressionStatement).Expression, "4:1", "4:18"},
+		{program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression).Arguments[1], "4:8", "4:17"},
+	}
+
+	for i, tt := range tests {
+		if got := tt.node.Pos().String(); got != tt.start {
+			t.Errorf("tests[%d] - %T start wrong. expected=%s, got=%s", i, tt.node, tt.start, got)
+		}
+		if got := tt.node.End().String(); got != tt.end {
+			t.Errorf("tests[%d] - %T end wrong. expected=%s, got=%s", i, tt.node, tt.end, got)
+		}
+	}
+}
+
+func TestParserDiagnostics(t *testing.T) {
+	input := "let x = (1 + 2;"
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	diagnostics := p.Diagnostics()
+	if len(diagnostics) == 0 {
+		t.Fatalf("expected diagnostics, got none")
+	}
+
+	d := diagnostics[0]
+	if d.Code != diagnostic.UnexpectedToken {
+		t.Errorf("wrong code. expected=%s, got=%s", diagnostic.UnexpectedToken, d.Code)
+	}
+	if d.Severity != diagnostic.Error {
+		t.Errorf("wrong severity. got=%s", d.Severity)
+	}
+	if len(d.Expected) != 1 || d.Expected[0] != token.RPAREN {
+		t.Errorf("wrong expected tokens. got=%v", d.Expected)
+	}
+	if d.Found != token.SEMICOLON {
+		t.Errorf("wrong found token. got=%s", d.Found)
+	}
+	if d.Span.Start.String() != "1:15" {
+		t.Errorf("wrong span start. got=%s", d.Span.Start)
+	}
+	if p.Errors()[0] != "1:15: expected next token to be ), got ; instead" {
+		t.Errorf("wrong error string. got=%q", p.Errors()[0])
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedErrors     []string
+		expectedStatements int
+	}{
+		{
+			"let x = 5 +; let y = 10;",
+			[]string{"1:12: no prefix parse function for ; found"},
+			1,
+		},
+		{
+			"let = 1; let y = 2; y",
+			[]string{"1:5: expected next token to be IDENT, got = instead"},
+			2,
+		},
+		{
+			"if (x { y } let a = 1;",
+			[]string{"1:7: expected next token to be ), got { instead"},
+			1,
+		},
+		{
+			"let f = fn(x) { x + ; x }; f(1) } let z = 3",
+			[]string{
+				"1:21: no prefix parse function for ; found",
+				"1:33: no prefix parse function for } found",
+			},
+			3,
+		},
+		{
+			"let a = 1 let b = ) while (true) { a } return 2",
+			[]string{"1:19: no prefix parse function for ) found"},
+			3,
+		},
+		{
+			"let a = 0x; let b = 12abc + 1; let c = 3",
+			[]string{
+				"1:9: hexadecimal literal has no digits",
+				"1:21: invalid character 'a' in decimal literal",
+			},
+			1,
+		},
+		{
+			"let a = add(1 @ 2); let b = 2",
+			[]string{`1:15: illegal character "@"`},
+			1,
+		},
+		{
+			"let a = \"abc\nlet b = 2",
+			[]string{"1:9: unterminated string"},
+			1,
+		},
+		{
+			`let a = "x ${}"; let b = "${1 2}"; let c = 3`,
+			[]string{
+				"1:14: expected an expression inside ${}",
+				"1:31: expected } to close the ${ in the string, got INT instead",
+			},
+			1,
+		},
+		{
+			`let h = {"a" 1}; let q = 2;`,
+			[]string{"1:14: expected next token to be :, got INT instead"},
+			1,
+		},
+		{
+			`[1, {"a" 1}]; let z = 1;`,
+			[]string{"1:10: expected next token to be :, got INT instead"},
+			1,
+		},
+		{
+			`if (true) { let a = {"x" 1}; } let b = 2;`,
+			[]string{"1:26: expected next token to be :, got INT instead"},
+			2,
+		},
+		{
+			`let a = f(1, [2, (3 4)]); let b = 2;`,
+			[]string{"1:21: expected next token to be ), got INT instead"},
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != len(tt.expectedErrors) {
+			t.Errorf("wrong number of errors for %q. expected=%d, got=%d (%q)",
+				tt.input, len(tt.expectedErrors), len(errors), errors)
+			continue
+		}
+		for i, msg := range tt.expectedErrors {
+			if errors[i] != msg {
+				t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, msg, errors[i])
+			}
+		}
+
+		if len(program.Statements) != tt.expectedStatements {
+			t.Errorf("wrong number of statements for %q. expected=%d, got=%d (%s)",
+				tt.input, tt.expectedStatements, len(program.Statements), program.String())
+		}
+	}
+}
+
+func TestAssignExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5", "(x = 5)"},
+		{"x += 1 + 2 * 3", "(x += (1 + (2 * 3)))"},
+		{"x -= y == z", "(x -= (y == z))"},
+		{"a = b = c", "(a = (b = c))"},
+		{"x *= f(y)", "(x *= f(y))"},
+		{"x /= 2; y", "(x /= 2)y"},
+		{"a[0] = 1", "((a[0]) = 1)"},
+		{"cfg.port = 80", "((cfg[port]) = 80)"},
+		{"h[\"k\"][i + 1] += 2", "(((h[k])[(i + 1)]) += 2)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+
+	l := lexer.New("a + b = 5; x = 1;")
+	p := New(l)
+	program := p.ParseProgram()
+
+	diagnostics := p.Diagnostics()
+	if len(diagnostics) != 1 || diagnostics[0].Code != diagnostic.InvalidAssign {
+		t.Fatalf("expected one InvalidAssign diagnostic, got=%v", p.Errors())
+	}
+	if diagnostics[0].Span.Start.Column != 1 || diagnostics[0].Span.End.Column != 6 {
+		t.Errorf("diagnostic should cover the target. got=%s-%s",
+			diagnostics[0].Span.Start, diagnostics[0].Span.End)
+	}
+	if len(program.Statements) != 1 {
+		t.Errorf("statement after the error was not parsed. got=%d statements", len(program.Statements))
+	}
+
+	for _, input := range []string{"a?[0] = 1", "a?.b = 1", "f(x) = 1"} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+
+		diagnostics := p.Diagnostics()
+		if len(diagnostics) != 1 || diagnostics[0].Code != diagnostic.InvalidAssign {
+			t.Errorf("expected one InvalidAssign diagnostic for %q, got=%v", input, p.Errors())
+		}
+	}
+}
+
+func TestLoopControlStatements(t *testing.T) {
+	input := `while (true) { if (x) { break; } continue }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.WhileStatement. got=%T", program.Statements[0])
+	}
+	if len(stmt.Body.Statements) != 2 {
+		t.Fatalf("body is not 2 statements. got=%d", len(stmt.Body.Statements))
+	}
+	ifExp := stmt.Body.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+	if _, ok := ifExp.Consequence.Statements[0].(*ast.BreakStatement); !ok {
+		t.Errorf("expected ast.BreakStatement. got=%T", ifExp.Consequence.Statements[0])
+	}
+	if _, ok := stmt.Body.Statements[1].(*ast.ContinueStatement); !ok {
+		t.Errorf("expected ast.ContinueStatement. got=%T", stmt.Body.Statements[1])
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"break;", "1:1: break outside of a loop"},
+		{"if (true) { continue; }", "1:13: continue outside of a loop"},
+		{"while (true) { let f = fn() { break; }; }", "1:31: break outside of a loop"},
+	}
+
+	for _, tt := range errorTests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 || errors[0] != tt.expected {
+			t.Errorf("wrong errors for %q. expected=%q, got=%q", tt.input, tt.expected, errors)
+		}
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14;", 3.14},
+		{".5", 0.5},
+		{"1e-9", 1e-9},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %g. got=%g", tt.expected, literal.Value)
+		}
+	}
+
+	l := lexer.New("1e999")
+	p := New(l)
+	p.ParseProgram()
+	if len(p.Diagnostics()) != 1 || p.Diagnostics()[0].Code != diagnostic.InvalidFloat {
+		t.Errorf("expected an InvalidFloat diagnostic. got=%v", p.Errors())
+	}
+}
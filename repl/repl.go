@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"monkey/diagnostic"
 	"monkey/evaluator"
 	"monkey/lexer"
 	"monkey/object"
@@ -29,7 +30,7 @@ func Start(in io.Reader, out io.Writer) {
 
 		program := p.ParseProgram()
 		if len(p.Errors()) != 0 {
-			printParseErrors(out,p.Errors())
+			printParseErrors(out, line, p.Diagnostics())
 			continue
 		}
 
@@ -41,12 +42,9 @@ func Start(in io.Reader, out io.Writer) {
 	}
 }
 
-// function to format writing out errors
-func printParseErrors(out io.Writer, errors []string) {
-	io.WriteString(out,"Error is detected\n")
-	io.WriteString(out,"parser errors:\n")
-
-	for _, msg := range errors {
-		io.WriteString(out,"\t"+msg+"\n")
+// function to format writing out errors, each one is shown under the line it points at
+func printParseErrors(out io.Writer, source string, diagnostics []diagnostic.Diagnostic) {
+	for _, d := range diagnostics {
+		io.WriteString(out, d.Render(source))
 	}
 }
@@ -1,16 +1,84 @@
 package token
 
+import "fmt"
+
 type TokenType string
 
 type Token struct {
-	Type    TokenType // name of the token
-	Literal string    // actual literal the token
+	Type     TokenType // name of the token
+	Literal  string    // actual literal the token
+	Span     Span      // where the token was found in the source
+	Comments []Comment // comments between the previous token and this one, only kept when the lexer is asked to
+}
+
+// Position is a location in the source, lines and columns start at 1
+type Position struct {
+	File   string // name of the file, empty for the REPL or tests
+	Line   int
+	Column int
+	Offset int // byte offset from the start of the input
+}
+
+// a position is valid once the lexer has filled in a line number
+func (p Position) IsValid() bool { return p.Line > 0 }
+
+// returns the position as "file:line:column" (file is left out when empty)
+func (p Position) String() string {
+	if !p.IsValid() {
+		if p.File != "" {
+			return p.File
+		}
+		return "-"
+	}
+	s := fmt.Sprintf("%d:%d", p.Line, p.Column)
+	if p.File != "" {
+		s = p.File + ":" + s
+	}
+	return s
+}
+
+// Span is the range of source covered by a token or node, End points just past the last character
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// Comment is a // or /* */ comment, Text includes the comment markers
+type Comment struct {
+	Text string
+	Span Span
 }
 
 const (
 	//two letter keywords
 	EQ     = "=="
 	NOT_EQ = "!="
+	AND    = "&&"
+	OR     = "||"
+	LT_EQ  = "<="
+	GT_EQ  = ">="
+	POWER  = "**"
+
+	//Null handling
+	NULLISH           = "??"
+	OPTIONAL_DOT      = "?."
+	OPTIONAL_LBRACKET = "?["
+
+	TUPLE_LPAREN = "#(" // opens a tuple, #(1, 2)
+
+	//Bitwise
+	AMPERSAND   = "&"
+	PIPE        = "|"
+	CARET       = "^"
+	TILDE       = "~"
+	SHIFT_LEFT  = "<<"
+	SHIFT_RIGHT = ">>"
+
+	//Compound assignment
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
 
 	//Special types
 	ILLEGAL = "ILLEGAL" // To signify a illegal token
@@ -19,8 +87,16 @@ const (
 	//Identifirers+ literals
 	IDENT  = "IDENT"
 	INT    = "INT"
+	FLOAT  = "FLOAT"
+	BIGINT = "BIGINT" // integer literal with an n suffix, 123n
 	STRING = "STRING"
 
+	// pieces of a string with embedded expressions, "a ${x} b ${y} c" is
+	// TEMPLATE_HEAD("a ") x TEMPLATE_MIDDLE(" b ") y TEMPLATE_TAIL(" c")
+	TEMPLATE_HEAD   = "TEMPLATE_HEAD"
+	TEMPLATE_MIDDLE = "TEMPLATE_MIDDLE"
+	TEMPLATE_TAIL   = "TEMPLATE_TAIL"
+
 	//Operators
 	ASSIGN   = "="
 	PLUS     = "+"
@@ -28,6 +104,7 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
 
 	LT = "<"
 	GT = ">"
@@ -36,6 +113,7 @@ const (
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
+	DOT       = "."
 
 	LPAREN   = "("
 	RPAREN   = ")"
@@ -53,17 +131,23 @@ const (
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
 	WHILE    = "WHILE" // added for the while loop
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	NULL     = "NULL"
 )
 
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
-	"while":  WHILE,
+	"fn":       FUNCTION,
+	"let":      LET,
+	"true":     TRUE,
+	"false":    FALSE,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"while":    WHILE,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"null":     NULL,
 }
 
 func GetIdentfierType(ident string) TokenType {